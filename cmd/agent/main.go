@@ -0,0 +1,18 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/flexer2006/y.lms_sprint1_Calc/internal/agent"
+)
+
+// Старт агента; остановка по Ctrl+C или SIGTERM
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	agent.New().Run(ctx)
+}
@@ -0,0 +1,289 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/flexer2006/y.lms_sprint1_Calc/internal/application"
+	"github.com/flexer2006/y.lms_sprint1_Calc/pkg/calculation"
+)
+
+// Agent получает у оркестратора операции, вычисляет их и возвращает результаты
+type Agent struct {
+	URL            string        // Адрес оркестратора
+	ComputingPower int           // Количество одновременно вычисляемых операций
+	PollInterval   time.Duration // Пауза, если готовых операций нет
+	Client         *http.Client
+	Logger         *log.Logger
+
+	// GRPCAddress адрес gRPC-сервиса оркестратора; если задан, операции
+	// приходят по gRPC вместо опроса URL
+	GRPCAddress string
+	// HeartbeatInterval наибольший интервал сигналов Heartbeat: продления аренды
+	// операции по HTTP и сообщений gRPC-сессии. Сигналы отправляются чаще, если
+	// аренда короче трех интервалов.
+	HeartbeatInterval time.Duration
+	// ReconnectInterval пауза перед повторным подключением по gRPC
+	ReconnectInterval time.Duration
+}
+
+// New создает агента по переменным окружения ORCHESTRATOR_URL, ORCHESTRATOR_GRPC
+// и COMPUTING_POWER
+func New() *Agent {
+	address := os.Getenv("ORCHESTRATOR_URL")
+	if address == "" {
+		address = "http://localhost:8080"
+	}
+	power, err := strconv.Atoi(os.Getenv("COMPUTING_POWER"))
+	if err != nil || power < 1 {
+		power = 1
+	}
+	return &Agent{
+		URL:            strings.TrimRight(address, "/"),
+		ComputingPower: power,
+		PollInterval:   100 * time.Millisecond,
+		Client:         &http.Client{Timeout: 10 * time.Second},
+		Logger:         log.New(os.Stdout, "", log.LstdFlags),
+
+		GRPCAddress:       os.Getenv("ORCHESTRATOR_GRPC"),
+		HeartbeatInterval: 5 * time.Second,
+		ReconnectInterval: time.Second,
+	}
+}
+
+// Run запускает ComputingPower вычислителей и ждет их остановки по ctx
+func (a *Agent) Run(ctx context.Context) {
+	if a.GRPCAddress != "" {
+		a.runGRPC(ctx)
+		return
+	}
+	a.Logger.Printf("Agent started with %d workers, orchestrator %s", a.ComputingPower, a.URL)
+
+	done := make(chan struct{})
+	for range a.ComputingPower {
+		go func() {
+			a.work(ctx)
+			done <- struct{}{}
+		}()
+	}
+	for range a.ComputingPower {
+		<-done
+	}
+}
+
+// work вычисляет операции, пока не отменен ctx
+func (a *Agent) work(ctx context.Context) {
+	for {
+		task, ok := a.fetch(ctx)
+		if !ok {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(a.PollInterval):
+			}
+			continue
+		}
+
+		taskCtx, cancel := context.WithCancel(ctx)
+		stopHeartbeats := a.keepLease(taskCtx, cancel, task.ID, task.LeaseExpiresAt)
+		value, err := compute(taskCtx, task.Operation, task.Args, task.OperationTime)
+		expires := stopHeartbeats()
+		lost := taskCtx.Err() != nil
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+		if lost {
+			a.Logger.Printf("Lease of task %s lost", task.ID)
+			continue
+		}
+
+		result := application.TaskResult{ID: task.ID, Result: value}
+		if err != nil {
+			result.Error = err.Error()
+		}
+		a.send(ctx, result, expires)
+	}
+}
+
+// keepLease продлевает аренду операции, пока она вычисляется. Если
+// оркестратор отказал в продлении, операция отдана другому агенту, и lost
+// отменяет ее вычисление. Возвращает функцию остановки, которая сообщает
+// последний известный срок аренды.
+func (a *Agent) keepLease(ctx context.Context, lost context.CancelFunc, id string, expires time.Time) func() time.Time {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		timer := time.NewTimer(a.heartbeatDelay(expires))
+		defer timer.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ctx.Done():
+				return
+			case <-timer.C:
+				code, extended := a.extendLease(ctx, id)
+				if code == http.StatusNotFound {
+					lost()
+					return
+				}
+				expires = later(expires, extended)
+				timer.Reset(a.heartbeatDelay(expires))
+			}
+		}
+	}()
+	return func() time.Time {
+		close(done)
+		<-stopped
+		return expires
+	}
+}
+
+// heartbeatDelay возвращает паузу до следующего продления аренды: не больше
+// HeartbeatInterval и трети времени до ее окончания, чтобы короткая аренда
+// (TASK_LEASE_MS) не истекала между сигналами
+func (a *Agent) heartbeatDelay(expires time.Time) time.Duration {
+	delay := a.HeartbeatInterval
+	if !expires.IsZero() {
+		if remaining := time.Until(expires) / 3; delay <= 0 || remaining < delay {
+			delay = remaining
+		}
+	}
+	return max(delay, time.Millisecond)
+}
+
+// later возвращает более поздний момент
+func later(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// extendLease продлевает аренду операции и возвращает код ответа и новый срок
+// аренды; код 0 - оркестратор недоступен
+func (a *Agent) extendLease(ctx context.Context, id string) (int, time.Time) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.URL+"/internal/task/"+url.PathEscape(id)+"/heartbeat", nil)
+	if err != nil {
+		return 0, time.Time{}
+	}
+	resp, err := a.Client.Do(req)
+	if err != nil {
+		return 0, time.Time{}
+	}
+	defer resp.Body.Close()
+
+	var lease application.LeaseResponse
+	if resp.StatusCode == http.StatusOK {
+		_ = json.NewDecoder(resp.Body).Decode(&lease)
+	}
+	return resp.StatusCode, lease.LeaseExpiresAt
+}
+
+// compute выдерживает длительность операции, которую задает оркестратор,
+// и вычисляет операцию
+func compute(ctx context.Context, operation string, args []float64, operationTime int64) (float64, error) {
+	select {
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	case <-time.After(time.Duration(operationTime) * time.Millisecond):
+	}
+	return calculation.ComputeTask(calculation.Task{Operation: operation, Args: args})
+}
+
+// fetch запрашивает операцию; false - операций нет или оркестратор недоступен
+func (a *Agent) fetch(ctx context.Context) (application.TaskResponse, bool) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.URL+"/internal/task", nil)
+	if err != nil {
+		return application.TaskResponse{}, false
+	}
+	resp, err := a.Client.Do(req)
+	if err != nil {
+		if ctx.Err() == nil {
+			a.Logger.Printf("Fetch task error: %v", err)
+		}
+		return application.TaskResponse{}, false
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return application.TaskResponse{}, false
+	}
+
+	var envelope application.TaskEnvelope
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		a.Logger.Printf("Invalid task: %v", err)
+		return application.TaskResponse{}, false
+	}
+	return envelope.Task, true
+}
+
+// Пауза между повторами отправки результата растет от sendRetryMin до sendRetryMax
+const (
+	sendRetryMin = 50 * time.Millisecond
+	sendRetryMax = 2 * time.Second
+)
+
+// send отправляет результат операции, повторяя отправку после сбоя сети или
+// ошибки оркестратора до окончания аренды: иначе операция была бы выдана снова
+// и засчитана как неудачная попытка. Повтор безопасен - результат той же
+// попытки оркестратор примет только один раз.
+func (a *Agent) send(ctx context.Context, result application.TaskResult, expires time.Time) {
+	body, err := json.Marshal(result)
+	if err != nil {
+		a.Logger.Printf("Encode result of task %s error: %v", result.ID, err)
+		return
+	}
+
+	delay := sendRetryMin
+	for {
+		code, err := a.post(ctx, body)
+		switch {
+		case code == http.StatusOK:
+			return
+		case code == http.StatusNotFound || code == http.StatusUnprocessableEntity:
+			// Аренда истекла или результат не принят - повтор не поможет
+			a.Logger.Printf("Result of task %s rejected: %d %s", result.ID, code, http.StatusText(code))
+			return
+		case err != nil:
+			a.Logger.Printf("Send result of task %s error: %v", result.ID, err)
+		default:
+			a.Logger.Printf("Send result of task %s error: %d %s", result.ID, code, http.StatusText(code))
+		}
+
+		if time.Now().Add(delay).After(expires) {
+			a.Logger.Printf("Result of task %s dropped: lease expired", result.ID)
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+		delay = min(2*delay, sendRetryMax)
+	}
+}
+
+// post отправляет результат операции и возвращает код ответа
+func (a *Agent) post(ctx context.Context, body []byte) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.URL+"/internal/task", bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := a.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,267 @@
+package agent_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/flexer2006/y.lms_sprint1_Calc/internal/agent"
+	"github.com/flexer2006/y.lms_sprint1_Calc/internal/application"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// agentCases выражения, которые агент вычисляет через оркестратор
+var agentCases = []struct {
+	name           string
+	expression     string
+	expectedStatus application.JobStatus
+	expectedResult float64
+}{
+	{"independent subtrees", "(1 + 2) * (3 + 4) - 2 ^ 3", application.JobDone, 13},
+	{"functions", "max(sqrt(16), 2 * 3) + -abs(-1)", application.JobDone, 5},
+	{"division by zero", "1 / (3 - 3)", application.JobError, 0},
+	{"not distributable", "det([1, 2; 3, 4])", application.JobDone, -2},
+}
+
+func TestAgent(t *testing.T) {
+	tests := []struct {
+		name string
+		grpc bool
+	}{
+		{"http", false},
+		{"grpc", true},
+	}
+
+	for _, transport := range tests {
+		t.Run(transport.name, func(t *testing.T) {
+			app := application.New()
+			app.Config.Distributed = true
+			app.Config.HeartbeatInterval = 10 * time.Millisecond
+			app.Logger = log.New(io.Discard, "", 0)
+			server := httptest.NewServer(app.Handler())
+			defer server.Close()
+
+			a := agent.New()
+			a.URL = server.URL
+			a.ComputingPower = 2
+			a.PollInterval = time.Millisecond
+			a.HeartbeatInterval = 10 * time.Millisecond
+			a.ReconnectInterval = time.Millisecond
+			a.Logger = log.New(io.Discard, "", 0)
+
+			if transport.grpc {
+				listener, err := net.Listen("tcp", "127.0.0.1:0")
+				require.NoError(t, err)
+				grpcServer := app.GRPCServer()
+				go grpcServer.Serve(listener)
+				defer grpcServer.Stop()
+
+				// Адрес HTTP недоступен: операции приходят только по gRPC
+				a.URL = "http://127.0.0.1:1"
+				a.GRPCAddress = listener.Addr().String()
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			stopped := make(chan struct{})
+			defer func() {
+				cancel()
+				<-stopped
+			}()
+			go func() {
+				a.Run(ctx)
+				close(stopped)
+			}()
+
+			for _, tt := range agentCases {
+				t.Run(tt.name, func(t *testing.T) {
+					job := evaluate(t, server.URL, tt.expression)
+					assert.Equal(t, tt.expectedStatus, job.Status, job.Error)
+					if tt.expectedStatus == application.JobDone {
+						require.NotNil(t, job.Result)
+						assert.InDelta(t, tt.expectedResult, job.Result.Result, 1e-9)
+					}
+				})
+			}
+		})
+	}
+}
+
+// evaluate отправляет выражение оркестратору и ждет завершения вычисления
+func evaluate(t *testing.T, url, expression string) application.Job {
+	body, err := json.Marshal(application.Request{Expression: expression})
+	require.NoError(t, err)
+	resp, err := http.Post(url+"/api/v1/expressions", "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	var created application.JobCreatedResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+
+	var job application.Job
+	require.Eventually(t, func() bool {
+		resp, err := http.Get(url + "/api/v1/expressions/" + created.ID)
+		if err != nil {
+			return false
+		}
+		defer resp.Body.Close()
+		job = application.Job{}
+		if json.NewDecoder(resp.Body).Decode(&job) != nil {
+			return false
+		}
+		return job.Status == application.JobDone || job.Status == application.JobError
+	}, 2*time.Second, 5*time.Millisecond)
+	return job
+}
+
+func TestNew(t *testing.T) {
+	t.Setenv("ORCHESTRATOR_URL", "http://orchestrator:8080/")
+	t.Setenv("COMPUTING_POWER", "4")
+	t.Setenv("ORCHESTRATOR_GRPC", "orchestrator:9090")
+
+	a := agent.New()
+	assert.Equal(t, "http://orchestrator:8080", a.URL)
+	assert.Equal(t, 4, a.ComputingPower)
+	assert.Equal(t, "orchestrator:9090", a.GRPCAddress)
+
+	t.Setenv("COMPUTING_POWER", "invalid")
+	assert.Equal(t, 1, agent.New().ComputingPower)
+}
+
+func TestAgent_LeaseLost(t *testing.T) {
+	var mu sync.Mutex
+	issued, heartbeats, results := 0, 0, 0
+	orchestrator := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/internal/task":
+			if issued > 0 {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			issued++
+			json.NewEncoder(w).Encode(application.TaskEnvelope{Task: application.TaskResponse{ID: "a-0-1", Operation: "+", Args: []float64{1, 2}, OperationTime: 200}})
+		case r.Method == http.MethodPost && r.URL.Path == "/internal/task/a-0-1/heartbeat":
+			// Аренда отдана другому агенту
+			heartbeats++
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodPost && r.URL.Path == "/internal/task":
+			results++
+		}
+	}))
+	defer orchestrator.Close()
+
+	a := agent.New()
+	a.URL = orchestrator.URL
+	a.PollInterval = time.Millisecond
+	a.HeartbeatInterval = 10 * time.Millisecond
+	a.Logger = log.New(io.Discard, "", 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	a.Run(ctx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 1, heartbeats, "после отказа аренда не продлевается")
+	assert.Equal(t, 0, results, "результат потерянной операции не отправляется")
+}
+
+func TestAgent_SendRetry(t *testing.T) {
+	var mu sync.Mutex
+	issued, attempts, accepted := 0, 0, 0
+	orchestrator := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/internal/task":
+			if issued > 0 {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			issued++
+			json.NewEncoder(w).Encode(application.TaskEnvelope{Task: application.TaskResponse{ID: "a-0-1", Operation: "+", Args: []float64{1, 2}, LeaseExpiresAt: time.Now().Add(time.Second)}})
+		case r.Method == http.MethodPost && r.URL.Path == "/internal/task":
+			// Оркестратор дважды недоступен, затем принимает результат
+			attempts++
+			if attempts < 3 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			var result application.TaskResult
+			if json.NewDecoder(r.Body).Decode(&result) == nil && result.Result == 3 {
+				accepted++
+			}
+		}
+	}))
+	defer orchestrator.Close()
+
+	a := agent.New()
+	a.URL = orchestrator.URL
+	a.PollInterval = time.Millisecond
+	a.Logger = log.New(io.Discard, "", 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	a.Run(ctx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 3, attempts)
+	assert.Equal(t, 1, accepted)
+}
+
+func TestAgent_ShortLease(t *testing.T) {
+	// Аренда короче интервала Heartbeat агента по умолчанию и самой операции
+	const lease = 60 * time.Millisecond
+	var mu sync.Mutex
+	var expires time.Time
+	issued, heartbeats, late, accepted := 0, 0, 0, 0
+	orchestrator := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == "/internal/task":
+			if issued > 0 {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			issued++
+			expires = time.Now().Add(lease)
+			json.NewEncoder(w).Encode(application.TaskEnvelope{Task: application.TaskResponse{ID: "a-0-1", Operation: "+", Args: []float64{1, 2}, OperationTime: 300, LeaseExpiresAt: expires}})
+		case r.Method == http.MethodPost && r.URL.Path == "/internal/task/a-0-1/heartbeat":
+			heartbeats++
+			if time.Now().After(expires) {
+				late++
+			}
+			expires = time.Now().Add(lease)
+			json.NewEncoder(w).Encode(application.LeaseResponse{LeaseExpiresAt: expires})
+		case r.Method == http.MethodPost && r.URL.Path == "/internal/task":
+			accepted++
+		}
+	}))
+	defer orchestrator.Close()
+
+	a := agent.New()
+	a.URL = orchestrator.URL
+	a.PollInterval = time.Millisecond
+	a.Logger = log.New(io.Discard, "", 0)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	a.Run(ctx)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.GreaterOrEqual(t, heartbeats, 4)
+	assert.Zero(t, late, "аренда истекла до сигнала Heartbeat")
+	assert.Equal(t, 1, accepted)
+}
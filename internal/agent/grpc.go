@@ -0,0 +1,149 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/flexer2006/y.lms_sprint1_Calc/internal/taskrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// heartbeatsMissed сколько интервалов Heartbeat можно не получать сообщений
+// от оркестратора до переподключения
+const heartbeatsMissed = 3
+
+// errHeartbeatTimeout оркестратор перестал отвечать
+var errHeartbeatTimeout = errors.New("orchestrator missed heartbeats")
+
+// runGRPC поддерживает gRPC-сессию с оркестратором, переподключаясь после обрыва
+func (a *Agent) runGRPC(ctx context.Context) {
+	a.Logger.Printf("Agent started with %d workers, orchestrator %s (gRPC)", a.ComputingPower, a.GRPCAddress)
+
+	conn, err := grpc.NewClient(a.GRPCAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		a.Logger.Printf("gRPC client error: %v", err)
+		return
+	}
+	defer conn.Close()
+	client := taskrpc.NewOrchestratorClient(conn)
+
+	for {
+		if err := a.session(ctx, client); err != nil && ctx.Err() == nil {
+			a.Logger.Printf("gRPC session error: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(a.ReconnectInterval):
+		}
+	}
+}
+
+// session запрашивает ComputingPower операций и после каждого результата -
+// следующую, так что агент не получает больше операций, чем вычисляет
+func (a *Agent) session(ctx context.Context, client taskrpc.OrchestratorClient) error {
+	// Вычисления ждем после отмены сессии
+	var computing sync.WaitGroup
+	defer computing.Wait()
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	stream, err := client.Connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	// Send нельзя вызывать одновременно из нескольких горутин
+	var sendMu sync.Mutex
+	send := func(message *taskrpc.AgentMessage) {
+		sendMu.Lock()
+		defer sendMu.Unlock()
+		if err := stream.Send(message); err != nil {
+			cancel(err)
+		}
+	}
+
+	send(&taskrpc.AgentMessage{Demand: int32(max(a.ComputingPower, 1))})
+
+	// Сигналы Heartbeat и проверка, что оркестратор жив. Сообщения продлевают
+	// аренду операций сессии, поэтому при короткой аренде сигналы чаще интервала.
+	var lastSeen sync.Mutex
+	seen := time.Now()
+	interval := max(a.HeartbeatInterval, time.Millisecond)
+	every := interval
+	shortened := make(chan time.Duration, 1)
+	ticker := time.NewTicker(every)
+	computing.Add(1)
+	go func() {
+		defer computing.Done()
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case every := <-shortened:
+				ticker.Reset(every)
+			case now := <-ticker.C:
+				lastSeen.Lock()
+				missed := now.Sub(seen) > heartbeatsMissed*interval
+				lastSeen.Unlock()
+				if missed {
+					cancel(errHeartbeatTimeout)
+					return
+				}
+				send(&taskrpc.AgentMessage{Heartbeat: &taskrpc.Heartbeat{SentAt: now.UnixMilli()}})
+			}
+		}
+	}()
+
+	for {
+		message, err := stream.Recv()
+		if err != nil {
+			if cause := context.Cause(ctx); cause != nil {
+				return cause
+			}
+			return err
+		}
+		lastSeen.Lock()
+		seen = time.Now()
+		lastSeen.Unlock()
+
+		task := message.Task
+		if task == nil {
+			continue
+		}
+		if lease := leaseLength(task); lease > 0 && lease/3 < every {
+			every = max(lease/3, time.Millisecond)
+			select {
+			case <-shortened:
+			default:
+			}
+			shortened <- every
+		}
+		computing.Add(1)
+		go func() {
+			defer computing.Done()
+			value, err := compute(ctx, task.Operation, task.Args, task.OperationTime)
+			if ctx.Err() != nil {
+				return
+			}
+			result := &taskrpc.TaskResult{Id: task.Id, Result: value}
+			if err != nil {
+				result.Error = err.Error()
+			}
+			send(&taskrpc.AgentMessage{Result: result, Demand: 1})
+		}()
+	}
+}
+
+// leaseLength оценивает, на сколько оркестратор продлевает аренду операции
+// сообщением сессии: аренда выдается на время операции и срок продления
+func leaseLength(task *taskrpc.Task) time.Duration {
+	if task.LeaseExpiresAt == 0 {
+		return 0
+	}
+	return time.Until(time.UnixMilli(task.LeaseExpiresAt)) - time.Duration(task.OperationTime)*time.Millisecond
+}
@@ -3,30 +3,241 @@ package application
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"math"
+	"math/big"
+	"net"
 	"net/http"
 	"os"
+	"runtime"
+	"strconv"
+	"time"
 
 	"github.com/flexer2006/y.lms_sprint1_Calc/pkg/calculation"
 )
 
 type Config struct {
-	Address string
-	Logger  *log.Logger
+	Address      string
+	Logger       *log.Logger
+	BatchWorkers int // Количество вычислителей общей очереди сервера
+
+	// Distributed включает распределенное вычисление асинхронных выражений:
+	// выражение разбивается на операции, которые вычисляют агенты
+	Distributed bool
+	// OperationTimes длительность операций, которую агент выдерживает перед
+	// вычислением, по оператору; для функций - по ключу FunctionOperation
+	OperationTimes map[string]time.Duration
+	// GRPCAddress адрес gRPC-сервиса для агентов; пустой - сервис не запускается
+	GRPCAddress string
+	// HeartbeatInterval интервал сигналов Heartbeat в gRPC-сессиях агентов
+	HeartbeatInterval time.Duration
+	// LeaseDuration на сколько агент арендует операцию сверх ее длительности и
+	// на сколько продлевает аренду сигнал Heartbeat
+	LeaseDuration time.Duration
+	// MaxTaskRetries сколько раз операция выдается повторно после истечения аренды;
+	// затем вычисление завершается ошибкой
+	MaxTaskRetries int
+	// JobsFile журнал асинхронных вычислений; пустой - вычисления не переживают перезапуск
+	JobsFile string
 }
 
 type Application struct {
 	Config *Config // Измените с config на Config
 	Logger *log.Logger
+
+	jobs      *jobStore  // Асинхронные вычисления
+	scheduler *scheduler // Общая очередь вычислений сервера
 }
+
+// Режимы вычисления выражения
+const (
+	ModeDefault     = ""            // Обычные числа
+	ModeUnits       = "units"       // Величины с единицами измерения: 5 km + 300 m
+	ModeUncertainty = "uncertainty" // Числа с погрешностью: 3.2±0.1 * 4.0±0.2
+	ModeInterval    = "interval"    // Гарантированные границы: interval(1, 2) / 3
+	ModeInteger     = "integer"     // Целые числа произвольной длины: 30!, nCr(100, 50)
+)
+
 type Request struct {
-	Expression string `json:"expression"`
+	Expression string             `json:"expression"`
+	Mode       string             `json:"mode,omitempty"`
+	Variables  map[string]float64 `json:"variables,omitempty"` // Значения переменных: {"x": 2}
+	Percent    bool               `json:"percent,omitempty"`   // % как на настольном калькуляторе: 200 + 15% = 230
+	// Неявное умножение: 2(3+4), 3pi
+	ImplicitMultiplication bool `json:"implicit_multiplication,omitempty"`
+	// Язык ввода чисел: в "ru-RU" дробная часть отделяется запятой, 3,5
+	Locale string `json:"locale,omitempty"`
+	// Переполнение, потеря значимости и NaN: "error" (по умолчанию), "saturate" или "allow"
+	SpecialValues string `json:"special_values,omitempty"`
+
+	// Форматирование результата в поле formatted
+	Decimals           *int   `json:"decimals,omitempty"`            // Знаков после точки
+	Significant        int    `json:"significant,omitempty"`         // Значащих цифр
+	Notation           string `json:"notation,omitempty"`            // fixed, sci или eng
+	Rounding           string `json:"rounding,omitempty"`            // half_even, half_up, half_down, up, down, ceiling, floor
+	ThousandsSeparator string `json:"thousands_separator,omitempty"` // Разделитель групп разрядов: "1 234 567"
+
+	Base           int   `json:"base,omitempty"`            // Основание системы счисления для поля in_base, от 2 до 36
+	MaxDenominator int64 `json:"max_denominator,omitempty"` // Наибольший знаменатель дроби в поле fraction
+
+	// Приоритет в очереди сервера: high, normal или low
+	Priority string `json:"priority,omitempty"`
+}
+
+// format возвращает настройки форматирования и признак того, что они заданы
+func (r Request) format() (calculation.FormatOptions, bool) {
+	options := calculation.FormatOptions{
+		Decimals:           r.Decimals,
+		Significant:        r.Significant,
+		Notation:           calculation.Notation(r.Notation),
+		Rounding:           calculation.Rounding(r.Rounding),
+		ThousandsSeparator: r.ThousandsSeparator,
+	}
+	return options, options != (calculation.FormatOptions{})
 }
 
+// Response результат вычисления. Бесконечность и NaN, допустимые при политике
+// "allow", записываются в JSON строками "Infinity", "-Infinity" и "NaN".
 type Response struct {
-	Result float64        `json:"result"`
-	Error  *ErrorResponse `json:"error,omitempty"`
+	Result           float64           `json:"result"`
+	Formatted        string            `json:"formatted,omitempty"` // Результат, записанный по настройкам форматирования
+	InBase           string            `json:"in_base,omitempty"`   // Результат в системе счисления base: "ff.8"
+	Fraction         string            `json:"fraction,omitempty"`  // Ближайшая дробь: "1/3"
+	Matrix           [][]float64       `json:"matrix,omitempty"`
+	Time             string            `json:"time,omitempty"`     // Момент времени в формате ISO 8601
+	Duration         string            `json:"duration,omitempty"` // Длительность в формате ISO 8601
+	Integer          string            `json:"integer,omitempty"`  // Точный целый результат десятичной строкой
+	Unit             string            `json:"unit,omitempty"`
+	Uncertainty      float64           `json:"uncertainty,omitempty"`
+	Interval         *IntervalResponse `json:"interval,omitempty"`
+	IntegrationError float64           `json:"integration_error,omitempty"` // Оценка абсолютной погрешности интегралов в выражении
+	Error            *ErrorResponse    `json:"error,omitempty"`
+}
+
+// MarshalJSON записывает особые значения строками, так как в JSON нет Inf и NaN
+func (r Response) MarshalJSON() ([]byte, error) {
+	type plain Response
+	return json.Marshal(struct {
+		Result jsonFloat `json:"result"`
+		plain
+		Matrix           [][]jsonFloat `json:"matrix,omitempty"`
+		Uncertainty      jsonFloat     `json:"uncertainty,omitempty"`
+		IntegrationError jsonFloat     `json:"integration_error,omitempty"`
+	}{jsonFloat(r.Result), plain(r), toJSONFloats(r.Matrix), jsonFloat(r.Uncertainty), jsonFloat(r.IntegrationError)})
+}
+
+// UnmarshalJSON читает ответ, в котором особые значения записаны строками
+func (r *Response) UnmarshalJSON(data []byte) error {
+	type plain Response
+	decoded := struct {
+		Result jsonFloat `json:"result"`
+		*plain
+		Matrix           [][]jsonFloat `json:"matrix,omitempty"`
+		Uncertainty      jsonFloat     `json:"uncertainty,omitempty"`
+		IntegrationError jsonFloat     `json:"integration_error,omitempty"`
+	}{plain: (*plain)(r)}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	r.Result, r.Uncertainty = float64(decoded.Result), float64(decoded.Uncertainty)
+	r.IntegrationError = float64(decoded.IntegrationError)
+	r.Matrix = nil
+	for _, row := range decoded.Matrix {
+		values := make([]float64, len(row))
+		for j, value := range row {
+			values[j] = float64(value)
+		}
+		r.Matrix = append(r.Matrix, values)
+	}
+	return nil
+}
+
+// toJSONFloatSlice готовит список чисел к записи в JSON
+func toJSONFloatSlice(values []float64) []jsonFloat {
+	result := make([]jsonFloat, len(values))
+	for i, value := range values {
+		result[i] = jsonFloat(value)
+	}
+	return result
+}
+
+// jsonFloat число, которое записывается в JSON строкой, если оно не конечно
+type jsonFloat float64
+
+func (f jsonFloat) MarshalJSON() ([]byte, error) {
+	switch x := float64(f); {
+	case math.IsNaN(x):
+		return []byte(`"NaN"`), nil
+	case math.IsInf(x, 1):
+		return []byte(`"Infinity"`), nil
+	case math.IsInf(x, -1):
+		return []byte(`"-Infinity"`), nil
+	default:
+		return json.Marshal(x)
+	}
+}
+
+func (f *jsonFloat) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return json.Unmarshal(data, (*float64)(f))
+	}
+	switch text {
+	case "NaN":
+		*f = jsonFloat(math.NaN())
+	case "Infinity":
+		*f = jsonFloat(math.Inf(1))
+	case "-Infinity":
+		*f = jsonFloat(math.Inf(-1))
+	default:
+		return fmt.Errorf("invalid number %q", text)
+	}
+	return nil
+}
+
+// toJSONFloats переводит строки матрицы в jsonFloat
+func toJSONFloats(rows [][]float64) [][]jsonFloat {
+	if rows == nil {
+		return nil
+	}
+	result := make([][]jsonFloat, len(rows))
+	for i, row := range rows {
+		result[i] = make([]jsonFloat, len(row))
+		for j, value := range row {
+			result[i][j] = jsonFloat(value)
+		}
+	}
+	return result
+}
+
+// IntervalResponse границы результата; отсутствующая граница означает бесконечность
+type IntervalResponse struct {
+	Lower *float64 `json:"lower"`
+	Upper *float64 `json:"upper"`
+}
+
+// SolveRequest запрос на решение уравнения вида "lhs = rhs"
+type SolveRequest struct {
+	Equation string    `json:"equation"`
+	Variable string    `json:"variable,omitempty"`
+	Bracket  []float64 `json:"bracket,omitempty"` // Отрезок поиска [a, b]
+	Guess    *float64  `json:"guess,omitempty"`   // Начальное приближение
+}
+
+// RootResponse найденный корень с диагностикой сходимости
+type RootResponse struct {
+	Value      float64 `json:"value"`
+	Residual   float64 `json:"residual"`
+	Iterations int     `json:"iterations"`
+	Method     string  `json:"method"`
+	Converged  bool    `json:"converged"`
+}
+
+type SolveResponse struct {
+	Roots       []RootResponse `json:"roots"`
+	Evaluations int            `json:"evaluations"`
 }
 
 func New() *Application {
@@ -37,12 +248,43 @@ func New() *Application {
 		port = "8080"
 	}
 
+	batchWorkers, err := strconv.Atoi(os.Getenv("BATCH_WORKERS"))
+	if err != nil || batchWorkers < 1 {
+		batchWorkers = runtime.NumCPU()
+	}
+
+	distributed, _ := strconv.ParseBool(os.Getenv("DISTRIBUTED"))
+
+	leaseDuration := 30 * time.Second
+	if ms, err := strconv.Atoi(os.Getenv("TASK_LEASE_MS")); err == nil && ms > 0 {
+		leaseDuration = time.Duration(ms) * time.Millisecond
+	}
+	maxTaskRetries, err := strconv.Atoi(os.Getenv("TASK_MAX_RETRIES"))
+	if err != nil || maxTaskRetries < 0 {
+		maxTaskRetries = 3
+	}
+
+	grpcAddress := ""
+	if grpcPort := os.Getenv("GRPC_PORT"); grpcPort != "" {
+		grpcAddress = fmt.Sprintf(":%s", grpcPort)
+	}
+
 	return &Application{
 		Config: &Config{
-			Address: fmt.Sprintf(":%s", port),
-			Logger:  logger,
+			Address:           fmt.Sprintf(":%s", port),
+			Logger:            logger,
+			BatchWorkers:      batchWorkers,
+			Distributed:       distributed,
+			OperationTimes:    operationTimesFromEnv(),
+			GRPCAddress:       grpcAddress,
+			HeartbeatInterval: 5 * time.Second,
+			LeaseDuration:     leaseDuration,
+			MaxTaskRetries:    maxTaskRetries,
+			JobsFile:          os.Getenv("JOBS_FILE"),
 		},
-		Logger: logger,
+		Logger:    logger,
+		jobs:      newJobStore(),
+		scheduler: newScheduler(),
 	}
 }
 
@@ -66,43 +308,340 @@ func (app *Application) CalcHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	var response Response
+	var errResponse *ErrorResponse
+	if !app.scheduleWait(r.Context(), priorityOf(req.Priority, PriorityHigh), clientID(r), func() {
+		response, errResponse = evaluate(req)
+	}) {
+		app.Logger.Printf("Calculation cancelled: %v", r.Context().Err())
+		return
+	}
+	if errResponse != nil {
+		app.SendError(w, errResponse.Code, errResponse.Error)
+		return
+	}
+
+	app.Logger.Printf("Calculated result: %f %s", response.Result, response.Unit)
+
+	app.SendJSON(w, http.StatusOK, response)
+}
+
+// evaluate вычисляет выражение запроса; при ошибке возвращает код и сообщение
+func evaluate(req Request) (Response, *ErrorResponse) {
+	calc, errResponse := prepare(req)
+	if errResponse != nil {
+		return Response{}, errResponse
+	}
+
+	var response Response
+	numeric := false // Результат - число, которое можно отформатировать
+	switch req.Mode {
+	case ModeDefault:
+		result, err := calc.Evaluate(req.Expression)
+		if err != nil {
+			return Response{}, calculationError(err)
+		}
+		switch result := result.(type) {
+		case calculation.Number:
+			response.Result, numeric = float64(result), true
+			response.IntegrationError = calc.IntegrationError()
+		case *calculation.Matrix:
+			response.Matrix = result.ToRows()
+		case calculation.Time:
+			response.Time = result.String()
+		case calculation.Duration:
+			response.Duration = result.String()
+		}
+
+	case ModeUnits:
+		result, err := calc.CalcQuantity(req.Expression)
+		if err != nil {
+			return Response{}, calculationError(err)
+		}
+		response.Result, response.Unit = result.Value, result.Unit
+		numeric = true
+
+	case ModeUncertainty:
+		result, err := calc.CalcUncertain(req.Expression)
+		if err != nil {
+			return Response{}, calculationError(err)
+		}
+		response.Result, response.Uncertainty = result.Value, result.Uncertainty
+		numeric = true
+
+	case ModeInterval:
+		result, err := calc.CalcInterval(req.Expression)
+		if err != nil {
+			return Response{}, calculationError(err)
+		}
+		response.Interval = &IntervalResponse{
+			Lower: finiteOrNil(result.Lower),
+			Upper: finiteOrNil(result.Upper),
+		}
+		if response.Interval.Lower != nil && response.Interval.Upper != nil {
+			response.Result = result.Lower + (result.Upper-result.Lower)/2
+		}
+
+	case ModeInteger:
+		result, err := calc.CalcInteger(req.Expression)
+		if err != nil {
+			return Response{}, calculationError(err)
+		}
+		response.Integer = result.String()
+		if req.Base != 0 {
+			response.InBase = result.Text(req.Base)
+		}
+		// Приближенное значение, если оно представимо в float64
+		if approximation, _ := new(big.Float).SetInt(result).Float64(); !math.IsInf(approximation, 0) {
+			response.Result = approximation
+		}
+
+	default:
+		return Response{}, &ErrorResponse{Error: "Unknown mode", Code: http.StatusBadRequest}
+	}
+
+	if numeric {
+		if errResponse := describeNumber(req, &response); errResponse != nil {
+			return Response{}, errResponse
+		}
+	}
+	return response, nil
+}
+
+// prepare проверяет настройки запроса и создает калькулятор с ними и переменными
+func prepare(req Request) (*calculation.Calculator, *ErrorResponse) {
 	if req.Expression == "" {
-		app.SendError(w, http.StatusBadRequest, "Expression is required")
+		return nil, &ErrorResponse{Error: "Expression is required", Code: http.StatusBadRequest}
+	}
+
+	specialValues, err := calculation.ParseSpecialValuesPolicy(req.SpecialValues)
+	if err != nil {
+		return nil, &ErrorResponse{Error: "Unknown special values policy", Code: http.StatusBadRequest}
+	}
+
+	format, _ := req.format()
+	if err := format.Validate(); err != nil {
+		return nil, &ErrorResponse{Error: "Invalid format options", Code: http.StatusBadRequest}
+	}
+
+	if req.Base != 0 && (req.Base < calculation.MinBase || req.Base > calculation.MaxBase) {
+		return nil, &ErrorResponse{Error: "Invalid base", Code: http.StatusBadRequest}
+	}
+	if req.MaxDenominator < 0 {
+		return nil, &ErrorResponse{Error: "Invalid max denominator", Code: http.StatusBadRequest}
+	}
+	if !validPriority(req.Priority) {
+		return nil, &ErrorResponse{Error: "Invalid priority", Code: http.StatusBadRequest}
+	}
+
+	calc := calculation.NewCalculator()
+	calc.SetOptions(calculation.Options{
+		Percent:                req.Percent,
+		ImplicitMultiplication: req.ImplicitMultiplication,
+		Locale:                 req.Locale,
+		SpecialValues:          specialValues,
+	})
+	for name, value := range req.Variables {
+		calc.SetVariable(name, value)
+	}
+	return calc, nil
+}
+
+// describeNumber дополняет числовой результат записью по настройкам форматирования,
+// в системе счисления и дробью
+func describeNumber(req Request, response *Response) *ErrorResponse {
+	if format, formatted := req.format(); formatted {
+		text, err := calculation.Format(response.Result, format)
+		if err != nil {
+			return calculationError(err)
+		}
+		response.Formatted = text
+	}
+	if req.Base != 0 {
+		text, err := calculation.FormatBase(response.Result, req.Base)
+		if err != nil {
+			return calculationError(err)
+		}
+		response.InBase = text
+	}
+	if req.MaxDenominator != 0 {
+		fraction, err := calculation.Rationalize(response.Result, req.MaxDenominator)
+		if err != nil {
+			return calculationError(err)
+		}
+		response.Fraction = fraction.String()
+	}
+	return nil
+}
+
+func (app *Application) SolveHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		app.SendError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
 		return
 	}
 
-	result, err := calculation.Calc(req.Expression)
+	var req SolveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		app.SendError(w, http.StatusBadRequest, "Invalid Request")
+		return
+	}
+
+	if req.Equation == "" {
+		app.SendError(w, http.StatusBadRequest, "Equation is required")
+		return
+	}
+
+	params := calculation.SolveParams{Guess: req.Guess}
+	if req.Bracket != nil {
+		if len(req.Bracket) != 2 {
+			app.SendError(w, http.StatusBadRequest, "Bracket must contain two numbers")
+			return
+		}
+		params.Bracket = &[2]float64{req.Bracket[0], req.Bracket[1]}
+	}
+
+	var result *calculation.SolveResult
+	var err error
+	if !app.scheduleWait(r.Context(), PriorityHigh, clientID(r), func() {
+		result, err = calculation.SolveEquation(req.Equation, req.Variable, params)
+	}) {
+		app.Logger.Printf("Solve cancelled: %v", r.Context().Err())
+		return
+	}
 	if err != nil {
 		app.handleCalculationError(w, err)
 		return
 	}
 
-	app.Logger.Printf("Calculated result: %f", result)
+	response := SolveResponse{
+		Roots:       make([]RootResponse, 0, len(result.Roots)),
+		Evaluations: result.Evaluations,
+	}
+	for _, root := range result.Roots {
+		response.Roots = append(response.Roots, RootResponse{
+			Value:      root.Value,
+			Residual:   root.Residual,
+			Iterations: root.Iterations,
+			Method:     root.Method,
+			Converged:  root.Converged,
+		})
+	}
 
-	app.SendJSON(w, http.StatusOK, Response{
-		Result: result,
-	})
+	app.Logger.Printf("Solved equation: %d root(s)", len(response.Roots))
+
+	app.SendJSON(w, http.StatusOK, response)
+}
+
+// finiteOrNil возвращает указатель на число или nil для бесконечности
+func finiteOrNil(value float64) *float64 {
+	if math.IsInf(value, 0) || math.IsNaN(value) {
+		return nil
+	}
+	return &value
 }
 
 func (app *Application) handleCalculationError(w http.ResponseWriter, err error) {
+	errResponse := calculationError(err)
+	app.SendError(w, errResponse.Code, errResponse.Error)
+}
+
+// calculationError сопоставляет ошибке вычисления HTTP-код и сообщение
+func calculationError(err error) *ErrorResponse {
+	var syntaxErr *calculation.SyntaxError
+	if errors.As(err, &syntaxErr) && errors.Is(err, calculation.ErrInvalidNumber) {
+		return &ErrorResponse{Error: fmt.Sprintf("Invalid number at position %d", syntaxErr.Position), Code: http.StatusBadRequest}
+	}
+	if errors.As(err, &syntaxErr) && errors.Is(err, calculation.ErrAmbiguousComma) {
+		return &ErrorResponse{Error: fmt.Sprintf("Ambiguous comma at position %d", syntaxErr.Position), Code: http.StatusBadRequest}
+	}
+
 	switch err {
 	case calculation.ErrInvalidExpression:
-		app.SendError(w, http.StatusBadRequest, "Expression is not valid")
+		return &ErrorResponse{Error: "Expression is not valid", Code: http.StatusBadRequest}
 
 	case calculation.ErrInvalidCharacter:
-		app.SendError(w, http.StatusBadRequest, "Expression is not valid")
+		return &ErrorResponse{Error: "Expression is not valid", Code: http.StatusBadRequest}
 
 	case calculation.ErrMismatchedParens:
-		app.SendError(w, http.StatusBadRequest, "Expression is not valid")
+		return &ErrorResponse{Error: "Expression is not valid", Code: http.StatusBadRequest}
 
 	case calculation.ErrDivisionByZero:
-		app.SendError(w, http.StatusUnprocessableEntity, "Division by Zero")
+		return &ErrorResponse{Error: "Division by Zero", Code: http.StatusUnprocessableEntity}
 
 	case calculation.ErrInvalidOperator:
-		app.SendError(w, http.StatusBadRequest, "Expression is not valid")
+		return &ErrorResponse{Error: "Expression is not valid", Code: http.StatusBadRequest}
+
+	case calculation.ErrUnknownVariable:
+		return &ErrorResponse{Error: "Unknown variable", Code: http.StatusBadRequest}
+
+	case calculation.ErrUnknownFunction:
+		return &ErrorResponse{Error: "Unknown function", Code: http.StatusBadRequest}
+
+	case calculation.ErrArgumentCount:
+		return &ErrorResponse{Error: "Wrong number of arguments", Code: http.StatusBadRequest}
+
+	case calculation.ErrInvalidArgument:
+		return &ErrorResponse{Error: "Invalid argument", Code: http.StatusUnprocessableEntity}
+
+	case calculation.ErrUnknownUnit:
+		return &ErrorResponse{Error: "Unknown unit", Code: http.StatusBadRequest}
+
+	case calculation.ErrDimensionMismatch:
+		return &ErrorResponse{Error: "Dimension mismatch", Code: http.StatusUnprocessableEntity}
+
+	case calculation.ErrEmptyInterval:
+		return &ErrorResponse{Error: "Empty interval", Code: http.StatusUnprocessableEntity}
+
+	case calculation.ErrNotScalar:
+		return &ErrorResponse{Error: "Result is not a number", Code: http.StatusUnprocessableEntity}
+
+	case calculation.ErrSingularMatrix:
+		return &ErrorResponse{Error: "Singular matrix", Code: http.StatusUnprocessableEntity}
+
+	case calculation.ErrInvalidEquation:
+		return &ErrorResponse{Error: "Equation is not valid", Code: http.StatusBadRequest}
+
+	case calculation.ErrInvalidBracket:
+		return &ErrorResponse{Error: "Bracket is not valid", Code: http.StatusBadRequest}
+
+	case calculation.ErrNoRoot:
+		return &ErrorResponse{Error: "No root found", Code: http.StatusUnprocessableEntity}
+
+	case calculation.ErrIdentity:
+		return &ErrorResponse{Error: "Equation holds for every value", Code: http.StatusUnprocessableEntity}
+
+	case calculation.ErrNotConverged:
+		return &ErrorResponse{Error: "Did not converge", Code: http.StatusUnprocessableEntity}
+
+	case calculation.ErrTypeMismatch:
+		return &ErrorResponse{Error: "Type mismatch", Code: http.StatusUnprocessableEntity}
+
+	case calculation.ErrInvalidDate:
+		return &ErrorResponse{Error: "Invalid date", Code: http.StatusBadRequest}
+
+	case calculation.ErrNotInteger:
+		return &ErrorResponse{Error: "Result is not an integer", Code: http.StatusUnprocessableEntity}
+
+	case calculation.ErrResultTooLarge:
+		return &ErrorResponse{Error: "Result is too large", Code: http.StatusUnprocessableEntity}
+
+	case calculation.ErrOverflow:
+		return &ErrorResponse{Error: "Result overflows", Code: http.StatusUnprocessableEntity}
+
+	case calculation.ErrUnderflow:
+		return &ErrorResponse{Error: "Result underflows", Code: http.StatusUnprocessableEntity}
+
+	case calculation.ErrNaN:
+		return &ErrorResponse{Error: "Result is undefined (NaN)", Code: http.StatusUnprocessableEntity}
+
+	case calculation.ErrInvalidFormat:
+		return &ErrorResponse{Error: "Invalid format options", Code: http.StatusBadRequest}
+
+	case calculation.ErrInvalidBase:
+		return &ErrorResponse{Error: "Invalid base", Code: http.StatusBadRequest}
 
 	default:
-		app.SendError(w, http.StatusInternalServerError, "Internal server error")
+		return &ErrorResponse{Error: "Internal server error", Code: http.StatusInternalServerError}
 	}
 }
 
@@ -117,19 +656,55 @@ func (app *Application) SendError(w http.ResponseWriter, code int, message strin
 }
 
 func (app *Application) SendJSON(w http.ResponseWriter, code int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
-
-	if err := json.NewEncoder(w).Encode(data); err != nil {
+	// Кодируем до записи заголовка, чтобы при ошибке отправить код 500
+	body, err := json.Marshal(data)
+	if err != nil {
 		app.Logger.Printf("Error encoding response: %v", err)
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		code = http.StatusInternalServerError
+		body, _ = json.Marshal(map[string]string{"error": "Internal server error"})
 	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	w.Write(append(body, '\n'))
 }
 
-func (app *Application) RunServer() error {
+// Handler возвращает маршрутизатор всех эндпоинтов сервера
+func (app *Application) Handler() http.Handler {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/calculate", app.LogMiddleware(app.CalcHandler))
+	mux.HandleFunc("/calculate/batch", app.LogMiddleware(app.BatchHandler))
+	mux.HandleFunc("/calculate/stream", app.LogMiddleware(app.StreamHandler))
+	mux.HandleFunc("/solve", app.LogMiddleware(app.SolveHandler))
+	mux.HandleFunc("POST /api/v1/expressions", app.LogMiddleware(app.CreateExpressionHandler))
+	mux.HandleFunc("GET /api/v1/expressions", app.LogMiddleware(app.ListExpressionsHandler))
+	mux.HandleFunc("GET /api/v1/expressions/{id}", app.LogMiddleware(app.GetExpressionHandler))
+	mux.HandleFunc("GET /api/v1/expressions/{id}/events", app.LogMiddleware(app.ExpressionEventsHandler))
+	mux.HandleFunc("GET /api/v1/queue", app.LogMiddleware(app.QueueStatsHandler))
+	mux.HandleFunc("GET /internal/task", app.LogMiddleware(app.GetTaskHandler))
+	mux.HandleFunc("POST /internal/task", app.LogMiddleware(app.PostTaskHandler))
+	mux.HandleFunc("POST /internal/task/{id}/heartbeat", app.LogMiddleware(app.ExtendLeaseHandler))
+	return mux
+}
+
+func (app *Application) RunServer() error {
+	if err := app.RestoreJobs(); err != nil {
+		return err
+	}
+
+	if app.Config.GRPCAddress != "" {
+		listener, err := net.Listen("tcp", app.Config.GRPCAddress)
+		if err != nil {
+			return err
+		}
+		app.Logger.Printf("Starting gRPC server on %s", app.Config.GRPCAddress)
+		go func() {
+			if err := app.GRPCServer().Serve(listener); err != nil {
+				app.Logger.Printf("gRPC server error: %v", err)
+			}
+		}()
+	}
 
 	app.Logger.Printf("Starting server on %s", app.Config.Address)
-	return http.ListenAndServe(app.Config.Address, mux)
+	return http.ListenAndServe(app.Config.Address, app.Handler())
 }
@@ -2,17 +2,31 @@
 package application_test
 
 import (
+	"bufio"
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
 	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/flexer2006/y.lms_sprint1_Calc/internal/application"
+	"github.com/flexer2006/y.lms_sprint1_Calc/internal/taskrpc"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
 )
 
 // TestNew проверяет создание приложения с различными портами - базовые случаи
@@ -240,14 +254,14 @@ func TestSendJSON(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, testData, response)
 
-	// t.Run("json encoding error", func(t *testing.T) {
-	// 	app := application.New()
-	// 	rec := httptest.NewRecorder()
+	t.Run("json encoding error", func(t *testing.T) {
+		app := application.New()
+		rec := httptest.NewRecorder()
 
-	// 	app.SendJSON(rec, http.StatusOK, make(chan int))
+		app.SendJSON(rec, http.StatusOK, make(chan int))
 
-	// 	assert.Equal(t, http.StatusInternalServerError, rec.Code)
-	// })
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	})
 }
 
 // "Хелп" функция для создания указателя на float64
@@ -305,3 +319,1530 @@ func TestErrorHandling(t *testing.T) {
 	assert.Equal(t, "Test Description", response.Error.Description)
 	assert.Equal(t, http.StatusBadRequest, response.Error.Code)
 }
+
+// TestSolveHandler тесты обработчика решения уравнений
+func TestSolveHandler(t *testing.T) {
+	tests := []struct {
+		name          string
+		method        string
+		body          string
+		expectedCode  int
+		expectedRoots []float64
+	}{
+		{
+			name:          "two roots",
+			method:        http.MethodPost,
+			body:          `{"equation":"x^2 - 2 = 0","variable":"x"}`,
+			expectedCode:  http.StatusOK,
+			expectedRoots: []float64{-1.41421356, 1.41421356},
+		},
+		{
+			name:          "bracket",
+			method:        http.MethodPost,
+			body:          `{"equation":"x^2 - 2 = 0","variable":"x","bracket":[0,2]}`,
+			expectedCode:  http.StatusOK,
+			expectedRoots: []float64{1.41421356},
+		},
+		{
+			name:          "initial guess",
+			method:        http.MethodPost,
+			body:          `{"equation":"price * 40 = 1000","variable":"price","guess":10}`,
+			expectedCode:  http.StatusOK,
+			expectedRoots: []float64{25},
+		},
+		{
+			name:         "invalid method",
+			method:       http.MethodGet,
+			expectedCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:         "invalid bracket",
+			method:       http.MethodPost,
+			body:         `{"equation":"x = 1","bracket":[1]}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "not an equation",
+			method:       http.MethodPost,
+			body:         `{"equation":"x + 1"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "no root",
+			method:       http.MethodPost,
+			body:         `{"equation":"x^2 + 1 = 0"}`,
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "identity",
+			method:       http.MethodPost,
+			body:         `{"equation":"x = x"}`,
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+	}
+
+	app := application.New()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/solve", bytes.NewBufferString(tt.body))
+			rec := httptest.NewRecorder()
+
+			http.HandlerFunc(app.SolveHandler).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			if tt.expectedRoots == nil {
+				return
+			}
+
+			var response application.SolveResponse
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+			require.Len(t, response.Roots, len(tt.expectedRoots))
+			for i, root := range response.Roots {
+				assert.InDelta(t, tt.expectedRoots[i], root.Value, 1e-6)
+				assert.True(t, root.Converged)
+			}
+		})
+	}
+}
+
+// TestCalcHandler_Units тесты вычислений с единицами измерения
+func TestCalcHandler_Units(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedCode   int
+		expectedResult float64
+		expectedUnit   string
+	}{
+		{"sum of lengths", `{"expression":"5 km + 300 m","mode":"units"}`, http.StatusOK, 5.3, "km"},
+		{"conversion", `{"expression":"to(3 ft, m)","mode":"units"}`, http.StatusOK, 0.9144, "m"},
+		{"dimension mismatch", `{"expression":"1 m + 1 s","mode":"units"}`, http.StatusUnprocessableEntity, 0, ""},
+		{"unknown mode", `{"expression":"1 + 1","mode":"magic"}`, http.StatusBadRequest, 0, ""},
+	}
+
+	app := application.New()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/calculate", bytes.NewBufferString(tt.body))
+			rec := httptest.NewRecorder()
+
+			http.HandlerFunc(app.CalcHandler).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedCode, rec.Code)
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+
+			var response application.Response
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+			assert.InDelta(t, tt.expectedResult, response.Result, 0.0001)
+			assert.Equal(t, tt.expectedUnit, response.Unit)
+		})
+	}
+}
+
+// TestCalcHandler_Uncertainty тесты вычислений с погрешностями
+func TestCalcHandler_Uncertainty(t *testing.T) {
+	app := application.New()
+
+	req := httptest.NewRequest(http.MethodPost, "/calculate",
+		bytes.NewBufferString(`{"expression":"10±0.3 + 5±0.4","mode":"uncertainty"}`))
+	rec := httptest.NewRecorder()
+
+	http.HandlerFunc(app.CalcHandler).ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var response application.Response
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.InDelta(t, 15, response.Result, 0.0001)
+	assert.InDelta(t, 0.5, response.Uncertainty, 0.0001)
+}
+
+// TestCalcHandler_Interval тесты интервальных вычислений
+func TestCalcHandler_Interval(t *testing.T) {
+	tests := []struct {
+		name         string
+		expression   string
+		lower, upper *float64
+	}{
+		{"bounded", "interval(1, 2) * 3", ptr(3), ptr(6)},
+		{"unbounded above", "1 / interval(0, 2)", ptr(0.5), nil},
+		{"entire line", "1 / interval(-1, 1)", nil, nil},
+	}
+
+	app := application.New()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(application.Request{Expression: tt.expression, Mode: application.ModeInterval})
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodPost, "/calculate", bytes.NewReader(body))
+			rec := httptest.NewRecorder()
+
+			http.HandlerFunc(app.CalcHandler).ServeHTTP(rec, req)
+
+			require.Equal(t, http.StatusOK, rec.Code)
+
+			var response application.Response
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+			require.NotNil(t, response.Interval)
+			for _, bound := range []struct{ expected, actual *float64 }{
+				{tt.lower, response.Interval.Lower},
+				{tt.upper, response.Interval.Upper},
+			} {
+				if bound.expected == nil {
+					assert.Nil(t, bound.actual)
+				} else {
+					require.NotNil(t, bound.actual)
+					assert.InDelta(t, *bound.expected, *bound.actual, 1e-9)
+				}
+			}
+		})
+	}
+}
+
+// TestCalcHandler_Matrix тесты вычислений с матрицами
+func TestCalcHandler_Matrix(t *testing.T) {
+	app := application.New()
+
+	req := httptest.NewRequest(http.MethodPost, "/calculate",
+		bytes.NewBufferString(`{"expression":"[1, 2; 3, 4] * [5; 6]"}`))
+	rec := httptest.NewRecorder()
+
+	http.HandlerFunc(app.CalcHandler).ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var response application.Response
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.Equal(t, [][]float64{{17}, {39}}, response.Matrix)
+
+	req = httptest.NewRequest(http.MethodPost, "/calculate",
+		bytes.NewBufferString(`{"expression":"inv([1, 2; 2, 4])"}`))
+	rec = httptest.NewRecorder()
+
+	http.HandlerFunc(app.CalcHandler).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
+
+func TestCalcHandler_Statistics(t *testing.T) {
+	app := application.New()
+
+	req := httptest.NewRequest(http.MethodPost, "/calculate",
+		bytes.NewBufferString(`{"expression":"mean([3, 5, 8]) * 1.2"}`))
+	rec := httptest.NewRecorder()
+
+	http.HandlerFunc(app.CalcHandler).ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var response application.Response
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.InDelta(t, 6.4, response.Result, 1e-9)
+}
+
+func TestCalcHandler_DateTime(t *testing.T) {
+	app := application.New()
+
+	tests := []struct {
+		name       string
+		expression string
+		expected   application.Response
+	}{
+		{"deadline", `date(\"2026-10-17T09:00\", \"Europe/Moscow\") + 30d`, application.Response{Time: "2026-11-16T09:00:00+03:00"}},
+		{"duration", "2h30m * 3", application.Response{Duration: "PT7H30M"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/calculate",
+				bytes.NewBufferString(`{"expression":"`+tt.expression+`"}`))
+			rec := httptest.NewRecorder()
+
+			http.HandlerFunc(app.CalcHandler).ServeHTTP(rec, req)
+
+			require.Equal(t, http.StatusOK, rec.Code)
+
+			var response application.Response
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+			assert.Equal(t, tt.expected, response)
+		})
+	}
+}
+
+func TestCalcHandler_Percent(t *testing.T) {
+	app := application.New()
+
+	req := httptest.NewRequest(http.MethodPost, "/calculate",
+		bytes.NewBufferString(`{"expression":"200 + 15%", "percent": true}`))
+	rec := httptest.NewRecorder()
+
+	http.HandlerFunc(app.CalcHandler).ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var response application.Response
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.InDelta(t, 230, response.Result, 1e-9)
+
+	req = httptest.NewRequest(http.MethodPost, "/calculate",
+		bytes.NewBufferString(`{"expression":"200 + 15%"}`))
+	rec = httptest.NewRecorder()
+
+	http.HandlerFunc(app.CalcHandler).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestCalcHandler_Integer(t *testing.T) {
+	app := application.New()
+
+	req := httptest.NewRequest(http.MethodPost, "/calculate",
+		bytes.NewBufferString(`{"expression":"30!", "mode": "integer"}`))
+	rec := httptest.NewRecorder()
+
+	http.HandlerFunc(app.CalcHandler).ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var response application.Response
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.Equal(t, "265252859812191058636308480000000", response.Integer)
+	assert.InDelta(t, 2.6525285981219107e32, response.Result, 1e18)
+
+	req = httptest.NewRequest(http.MethodPost, "/calculate",
+		bytes.NewBufferString(`{"expression":"7 / 2", "mode": "integer"}`))
+	rec = httptest.NewRecorder()
+
+	http.HandlerFunc(app.CalcHandler).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+}
+
+func TestCalcHandler_ImplicitMultiplication(t *testing.T) {
+	app := application.New()
+
+	req := httptest.NewRequest(http.MethodPost, "/calculate",
+		bytes.NewBufferString(`{"expression":"2(3+4) + 1/2pi", "implicit_multiplication": true}`))
+	rec := httptest.NewRecorder()
+
+	http.HandlerFunc(app.CalcHandler).ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var response application.Response
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.InDelta(t, 14+1/(2*math.Pi), response.Result, 1e-9)
+}
+
+func TestCalcHandler_IntegrationError(t *testing.T) {
+	app := application.New()
+
+	req := httptest.NewRequest(http.MethodPost, "/calculate",
+		bytes.NewBufferString(`{"expression":"integrate(1 / (1 + 10000 * x^2), x, -1, 1)"}`))
+	rec := httptest.NewRecorder()
+
+	http.HandlerFunc(app.CalcHandler).ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var response application.Response
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.InDelta(t, 0.02*math.Atan(100), response.Result, 1e-9)
+	assert.Positive(t, response.IntegrationError)
+	assert.Less(t, response.IntegrationError, 1e-8)
+}
+
+func TestCalcHandler_Locale(t *testing.T) {
+	app := application.New()
+
+	req := httptest.NewRequest(http.MethodPost, "/calculate",
+		bytes.NewBufferString(`{"expression":"1 000,5 × 2 − √4", "locale": "ru-RU"}`))
+	rec := httptest.NewRecorder()
+
+	http.HandlerFunc(app.CalcHandler).ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var response application.Response
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.InDelta(t, 1999, response.Result, 1e-9)
+}
+
+func TestCalcHandler_LocaleAmbiguousComma(t *testing.T) {
+	app := application.New()
+
+	req := httptest.NewRequest(http.MethodPost, "/calculate",
+		bytes.NewBufferString(`{"expression":"nCr(5,2)", "locale": "ru-RU"}`))
+	rec := httptest.NewRecorder()
+
+	http.HandlerFunc(app.CalcHandler).ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var response map[string]string
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.Equal(t, "Ambiguous comma at position 5", response["error"])
+}
+
+func TestCalcHandler_InvalidNumber(t *testing.T) {
+	app := application.New()
+
+	req := httptest.NewRequest(http.MethodPost, "/calculate",
+		bytes.NewBufferString(`{"expression":"2 + 1.2.3"}`))
+	rec := httptest.NewRecorder()
+
+	http.HandlerFunc(app.CalcHandler).ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+
+	var response map[string]string
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.Equal(t, "Invalid number at position 7", response["error"])
+}
+
+func TestCalcHandler_SpecialValues(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"overflow error by default", `{"expression":"1e308*10"}`, http.StatusUnprocessableEntity, `{"error":"Result overflows"}`},
+		{"underflow error", `{"expression":"1e-200*1e-200"}`, http.StatusUnprocessableEntity, `{"error":"Result underflows"}`},
+		{"nan error", `{"expression":"(-8)^(1/3)"}`, http.StatusUnprocessableEntity, `{"error":"Result is undefined (NaN)"}`},
+		{"saturate", `{"expression":"1e308*10", "special_values": "saturate"}`, http.StatusOK, `{"result":1.7976931348623157e+308}`},
+		{"allow infinity", `{"expression":"-1e308*10", "special_values": "allow"}`, http.StatusOK, `{"result":"-Infinity"}`},
+		{"allow nan", `{"expression":"1e308*10 - 1e308*10", "special_values": "allow"}`, http.StatusOK, `{"result":"NaN"}`},
+		{"allow matrix", `{"expression":"[1e308, 1] * 10", "special_values": "allow"}`, http.StatusOK, `{"result":0,"matrix":[["Infinity",10]]}`},
+		{"unknown policy", `{"expression":"1", "special_values": "ignore"}`, http.StatusBadRequest, `{"error":"Unknown special values policy"}`},
+		{"units overflow error", `{"expression":"1e308 m * 10", "mode": "units"}`, http.StatusUnprocessableEntity, `{"error":"Result overflows"}`},
+		{"units nan error", `{"expression":"(-8)^(1/3)", "mode": "units"}`, http.StatusUnprocessableEntity, `{"error":"Result is undefined (NaN)"}`},
+		{"uncertainty underflow error", `{"expression":"1e-200±1e-201 * 1e-200", "mode": "uncertainty"}`, http.StatusUnprocessableEntity, `{"error":"Result underflows"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := application.New()
+
+			req := httptest.NewRequest(http.MethodPost, "/calculate", bytes.NewBufferString(tt.body))
+			rec := httptest.NewRecorder()
+
+			http.HandlerFunc(app.CalcHandler).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.JSONEq(t, tt.expectedBody, rec.Body.String())
+		})
+	}
+}
+
+func TestResponse_SpecialValuesRoundTrip(t *testing.T) {
+	original := application.Response{Result: math.Inf(1), Matrix: [][]float64{{math.Inf(-1), 2}}, Uncertainty: 0.5, IntegrationError: math.Inf(1)}
+
+	data, err := json.Marshal(original)
+	require.NoError(t, err)
+
+	var decoded application.Response
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Equal(t, original, decoded)
+}
+
+func TestCalcHandler_Format(t *testing.T) {
+	tests := []struct {
+		name              string
+		body              string
+		expectedStatus    int
+		expectedFormatted string
+	}{
+		{"decimals", `{"expression":"2/3", "decimals": 3}`, http.StatusOK, "0.667"},
+		{"rounding", `{"expression":"5/2", "decimals": 0, "rounding": "half_up"}`, http.StatusOK, "3"},
+		{"significant and separator", `{"expression":"1234567.891", "significant": 8, "thousands_separator": " "}`, http.StatusOK, "1 234 567.9"},
+		{"scientific", `{"expression":"6.02214076e23", "notation": "sci", "decimals": 2}`, http.StatusOK, "6.02e23"},
+		{"engineering units", `{"expression":"4700 m", "mode": "units", "notation": "eng"}`, http.StatusOK, "4.7e3"},
+		{"no format options", `{"expression":"2/3"}`, http.StatusOK, ""},
+		{"invalid notation", `{"expression":"1", "notation": "hex"}`, http.StatusBadRequest, ""},
+		{"decimals with significant", `{"expression":"1", "decimals": 2, "significant": 3}`, http.StatusBadRequest, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := application.New()
+
+			req := httptest.NewRequest(http.MethodPost, "/calculate", bytes.NewBufferString(tt.body))
+			rec := httptest.NewRecorder()
+
+			http.HandlerFunc(app.CalcHandler).ServeHTTP(rec, req)
+
+			require.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var response application.Response
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+			assert.Equal(t, tt.expectedFormatted, response.Formatted)
+		})
+	}
+}
+
+func TestCalcHandler_BaseAndFraction(t *testing.T) {
+	tests := []struct {
+		name             string
+		body             string
+		expectedStatus   int
+		expectedInBase   string
+		expectedFraction string
+	}{
+		{"hexadecimal", `{"expression":"255.5", "base": 16}`, http.StatusOK, "ff.8", ""},
+		{"binary", `{"expression":"2^10 - 1", "base": 2}`, http.StatusOK, "1111111111", ""},
+		{"fraction", `{"expression":"0.333333", "max_denominator": 100}`, http.StatusOK, "", "1/3"},
+		{"both", `{"expression":"0.75", "base": 2, "max_denominator": 10}`, http.StatusOK, "0.11", "3/4"},
+		{"integer mode base", `{"expression":"2^100", "mode": "integer", "base": 16}`, http.StatusOK, "10000000000000000000000000", ""},
+		{"invalid base", `{"expression":"1", "base": 37}`, http.StatusBadRequest, "", ""},
+		{"invalid max denominator", `{"expression":"1", "max_denominator": -1}`, http.StatusBadRequest, "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := application.New()
+
+			req := httptest.NewRequest(http.MethodPost, "/calculate", bytes.NewBufferString(tt.body))
+			rec := httptest.NewRecorder()
+
+			http.HandlerFunc(app.CalcHandler).ServeHTTP(rec, req)
+
+			require.Equal(t, tt.expectedStatus, rec.Code)
+			if tt.expectedStatus != http.StatusOK {
+				return
+			}
+			var response application.Response
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+			assert.Equal(t, tt.expectedInBase, response.InBase)
+			assert.Equal(t, tt.expectedFraction, response.Fraction)
+		})
+	}
+}
+
+func TestBatchHandler(t *testing.T) {
+	app := application.New()
+	app.Config.BatchWorkers = 4
+
+	req := httptest.NewRequest(http.MethodPost, "/calculate/batch", bytes.NewBufferString(`[
+		{"expression": "2 + 2"},
+		{"expression": "x * y", "variables": {"x": 3, "y": 4}},
+		{"expression": "1 / 0"},
+		{"expression": "5 km + 300 m", "mode": "units"},
+		{"expression": ""},
+		{"expression": "2/3", "decimals": 2}
+	]`))
+	rec := httptest.NewRecorder()
+
+	http.HandlerFunc(app.BatchHandler).ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var response application.BatchResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	require.Len(t, response.Results, 6)
+
+	assert.Equal(t, 4.0, response.Results[0].Result)
+	assert.Equal(t, 12.0, response.Results[1].Result)
+	assert.Equal(t, &application.ErrorResponse{Error: "Division by Zero", Code: http.StatusUnprocessableEntity}, response.Results[2].Error)
+	assert.Equal(t, "km", response.Results[3].Unit)
+	assert.Equal(t, &application.ErrorResponse{Error: "Expression is required", Code: http.StatusBadRequest}, response.Results[4].Error)
+	assert.Equal(t, "0.67", response.Results[5].Formatted)
+}
+
+func TestBatchHandler_Order(t *testing.T) {
+	app := application.New()
+	app.Config.BatchWorkers = 8
+
+	var body bytes.Buffer
+	body.WriteString("[")
+	for i := range 1000 {
+		if i > 0 {
+			body.WriteString(",")
+		}
+		fmt.Fprintf(&body, `{"expression": "%d * 2"}`, i)
+	}
+	body.WriteString("]")
+
+	req := httptest.NewRequest(http.MethodPost, "/calculate/batch", &body)
+	rec := httptest.NewRecorder()
+
+	http.HandlerFunc(app.BatchHandler).ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var response application.BatchResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	require.Len(t, response.Results, 1000)
+	for i, result := range response.Results {
+		assert.Equal(t, float64(i*2), result.Result)
+	}
+}
+
+func TestBatchHandler_Errors(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"invalid method", http.MethodGet, "", http.StatusMethodNotAllowed, `{"error":"Method Not Allowed"}`},
+		{"not an array", http.MethodPost, `{"expression": "1"}`, http.StatusBadRequest, `{"error":"Invalid Request"}`},
+		{"empty batch", http.MethodPost, `[]`, http.StatusOK, `{"results":[]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := application.New()
+
+			req := httptest.NewRequest(tt.method, "/calculate/batch", bytes.NewBufferString(tt.body))
+			rec := httptest.NewRecorder()
+
+			http.HandlerFunc(app.BatchHandler).ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+			assert.JSONEq(t, tt.expectedBody, rec.Body.String())
+		})
+	}
+}
+
+func TestStreamHandler(t *testing.T) {
+	app := application.New()
+	app.Config.BatchWorkers = 3
+
+	body := `{"expression": "2 + 2"}
+{"expression": "x * 2", "variables": {"x": 21}}
+
+{"expression": "1 / 0"}
+not json
+{"expression": "sqrt(16)"}
+`
+	req := httptest.NewRequest(http.MethodPost, "/calculate/stream", bytes.NewBufferString(body))
+	rec := httptest.NewRecorder()
+
+	http.HandlerFunc(app.StreamHandler).ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/x-ndjson", rec.Header().Get("Content-Type"))
+
+	decoder := json.NewDecoder(rec.Body)
+	var results []application.Response
+	for decoder.More() {
+		var response application.Response
+		require.NoError(t, decoder.Decode(&response))
+		results = append(results, response)
+	}
+
+	require.Len(t, results, 5)
+	assert.Equal(t, 4.0, results[0].Result)
+	assert.Equal(t, 42.0, results[1].Result)
+	assert.Equal(t, &application.ErrorResponse{Error: "Division by Zero", Code: http.StatusUnprocessableEntity}, results[2].Error)
+	assert.Equal(t, &application.ErrorResponse{Error: "Invalid Request", Code: http.StatusBadRequest}, results[3].Error)
+	assert.Equal(t, 4.0, results[4].Result)
+}
+
+func TestStreamHandler_Incremental(t *testing.T) {
+	app := application.New()
+	server := httptest.NewServer(http.HandlerFunc(app.StreamHandler))
+	defer server.Close()
+
+	// Результат первой строки приходит до того, как клиент закончил запрос
+	reader, writer := io.Pipe()
+	defer writer.Close()
+
+	responses := make(chan *http.Response, 1)
+	go func() {
+		resp, err := http.Post(server.URL, "application/x-ndjson", reader)
+		if err == nil {
+			responses <- resp
+		}
+	}()
+
+	_, err := writer.Write([]byte(`{"expression": "6 * 7"}` + "\n"))
+	require.NoError(t, err)
+
+	resp := <-responses
+	defer resp.Body.Close()
+
+	var response application.Response
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&response))
+	assert.Equal(t, 42.0, response.Result)
+}
+
+func TestStreamHandler_InvalidMethod(t *testing.T) {
+	app := application.New()
+
+	req := httptest.NewRequest(http.MethodGet, "/calculate/stream", nil)
+	rec := httptest.NewRecorder()
+
+	http.HandlerFunc(app.StreamHandler).ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestExpressions(t *testing.T) {
+	app := application.New()
+	handler := app.Handler()
+
+	submit := func(body string) string {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/expressions", bytes.NewBufferString(body))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusCreated, rec.Code)
+
+		var created application.JobCreatedResponse
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&created))
+		require.NotEmpty(t, created.ID)
+		return created.ID
+	}
+	get := func(id string) application.Job {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/expressions/"+id, nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		var job application.Job
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&job))
+		return job
+	}
+	finished := func(id string) func() bool {
+		return func() bool {
+			status := get(id).Status
+			return status == application.JobDone || status == application.JobError
+		}
+	}
+
+	doneID := submit(`{"expression": "x^2 + 1", "variables": {"x": 3}}`)
+	errorID := submit(`{"expression": "1 / 0"}`)
+
+	require.Eventually(t, finished(doneID), time.Second, 5*time.Millisecond)
+	require.Eventually(t, finished(errorID), time.Second, 5*time.Millisecond)
+
+	done := get(doneID)
+	assert.Equal(t, application.JobDone, done.Status)
+	assert.Equal(t, "x^2 + 1", done.Expression)
+	require.NotNil(t, done.Result)
+	assert.Equal(t, 10.0, done.Result.Result)
+
+	failed := get(errorID)
+	assert.Equal(t, application.JobError, failed.Status)
+	assert.Equal(t, &application.ErrorResponse{Error: "Division by Zero", Code: http.StatusUnprocessableEntity}, failed.Error)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/expressions", nil)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var list application.JobListResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&list))
+	require.Len(t, list.Expressions, 2)
+	assert.Equal(t, doneID, list.Expressions[0].ID)
+	assert.Equal(t, errorID, list.Expressions[1].ID)
+}
+
+func TestExpressions_Errors(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		expectedStatus int
+	}{
+		{"empty expression", http.MethodPost, "/api/v1/expressions", `{"expression": ""}`, http.StatusBadRequest},
+		{"invalid json", http.MethodPost, "/api/v1/expressions", `{`, http.StatusBadRequest},
+		{"unknown id", http.MethodGet, "/api/v1/expressions/unknown", "", http.StatusNotFound},
+		{"invalid method", http.MethodDelete, "/api/v1/expressions", "", http.StatusMethodNotAllowed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := application.New()
+
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			rec := httptest.NewRecorder()
+
+			app.Handler().ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestTasks(t *testing.T) {
+	app := application.New()
+	app.Config.Distributed = true
+	app.Config.OperationTimes = map[string]time.Duration{"+": 50 * time.Millisecond}
+	handler := app.Handler()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/expressions", bytes.NewBufferString(`{"expression": "(1 + 2) * (3 + 4)"}`))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	var created application.JobCreatedResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&created))
+
+	fetch := func() (application.TaskResponse, int) {
+		req := httptest.NewRequest(http.MethodGet, "/internal/task", nil)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		var envelope application.TaskEnvelope
+		if rec.Code == http.StatusOK {
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&envelope))
+		}
+		return envelope.Task, rec.Code
+	}
+	send := func(result string) int {
+		req := httptest.NewRequest(http.MethodPost, "/internal/task", bytes.NewBufferString(result))
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	// Сложения независимы и выдаются сразу, умножение - после них
+	first, code := fetch()
+	require.Equal(t, http.StatusOK, code)
+	second, code := fetch()
+	require.Equal(t, http.StatusOK, code)
+	_, code = fetch()
+	assert.Equal(t, http.StatusNotFound, code)
+
+	assert.Equal(t, application.TaskResponse{ID: first.ID, Operation: "+", Args: []float64{1, 2}, OperationTime: 50, LeaseExpiresAt: first.LeaseExpiresAt}, first)
+	assert.Equal(t, application.TaskResponse{ID: second.ID, Operation: "+", Args: []float64{3, 4}, OperationTime: 50, LeaseExpiresAt: second.LeaseExpiresAt}, second)
+	assert.WithinDuration(t, time.Now().Add(app.Config.LeaseDuration+50*time.Millisecond), first.LeaseExpiresAt, time.Second)
+
+	assert.Equal(t, http.StatusOK, send(fmt.Sprintf(`{"id": %q, "result": 3}`, first.ID)))
+	assert.Equal(t, http.StatusNotFound, send(fmt.Sprintf(`{"id": %q, "result": 3}`, first.ID)))
+	assert.Equal(t, http.StatusOK, send(fmt.Sprintf(`{"id": %q, "result": 7}`, second.ID)))
+
+	product, code := fetch()
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "*", product.Operation)
+	assert.Equal(t, []float64{3, 7}, product.Args)
+	assert.Equal(t, int64(0), product.OperationTime)
+	assert.Equal(t, http.StatusOK, send(fmt.Sprintf(`{"id": %q, "result": 21}`, product.ID)))
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/expressions/"+created.ID, nil)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	var job application.Job
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&job))
+	assert.Equal(t, application.JobDone, job.Status)
+	require.NotNil(t, job.Result)
+	assert.Equal(t, 21.0, job.Result.Result)
+}
+
+func TestTasks_Errors(t *testing.T) {
+	tests := []struct {
+		name          string
+		expression    string
+		result        string
+		expectedError *application.ErrorResponse
+	}{
+		{"computation error", "1 / (2 - 2)", `{"id": %q, "error": "division by zero"}`, &application.ErrorResponse{Error: "Division by Zero", Code: http.StatusUnprocessableEntity}},
+		{"overflow", "2 * 3", `{"id": %q, "result": "Infinity"}`, &application.ErrorResponse{Error: "Result overflows", Code: http.StatusUnprocessableEntity}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := application.New()
+			app.Config.Distributed = true
+			handler := app.Handler()
+
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/expressions", bytes.NewBufferString(fmt.Sprintf(`{"expression": %q}`, tt.expression)))
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			var created application.JobCreatedResponse
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&created))
+
+			req = httptest.NewRequest(http.MethodGet, "/internal/task", nil)
+			rec = httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Code)
+			var envelope application.TaskEnvelope
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&envelope))
+
+			req = httptest.NewRequest(http.MethodPost, "/internal/task", bytes.NewBufferString(fmt.Sprintf(tt.result, envelope.Task.ID)))
+			rec = httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Code)
+
+			req = httptest.NewRequest(http.MethodGet, "/api/v1/expressions/"+created.ID, nil)
+			rec = httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			var job application.Job
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&job))
+			assert.Equal(t, application.JobError, job.Status)
+			assert.Equal(t, tt.expectedError, job.Error)
+		})
+	}
+}
+
+// connectGRPC запускает gRPC-сервис приложения и открывает сессию агента
+func connectGRPC(t *testing.T, app *application.Application) taskrpc.Orchestrator_ConnectClient {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := app.GRPCServer()
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+	stream, err := taskrpc.NewOrchestratorClient(conn).Connect(ctx)
+	require.NoError(t, err)
+	return stream
+}
+
+func TestGRPC(t *testing.T) {
+	app := application.New()
+	app.Config.Distributed = true
+	app.Config.HeartbeatInterval = time.Hour
+	app.Config.OperationTimes = map[string]time.Duration{application.FunctionOperation: 20 * time.Millisecond}
+	handler := app.Handler()
+	stream := connectGRPC(t, app)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/expressions", bytes.NewBufferString(`{"expression": "sqrt(4) + sqrt(9) + sqrt(16)"}`))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	var created application.JobCreatedResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&created))
+
+	// Агент запросил две операции из трех готовых
+	require.NoError(t, stream.Send(&taskrpc.AgentMessage{Demand: 2}))
+	first, err := stream.Recv()
+	require.NoError(t, err)
+	second, err := stream.Recv()
+	require.NoError(t, err)
+	assert.True(t, proto.Equal(&taskrpc.Task{Id: first.Task.Id, Operation: "sqrt", Args: []float64{4}, OperationTime: 20, LeaseExpiresAt: first.Task.LeaseExpiresAt}, first.Task), "%v", first.Task)
+	assert.Greater(t, first.Task.LeaseExpiresAt, time.Now().UnixMilli())
+	assert.Equal(t, []float64{9}, second.Task.Args)
+
+	// Третья операция осталась в очереди
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/internal/task", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var third application.TaskEnvelope
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&third))
+	assert.Equal(t, []float64{16}, third.Task.Args)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/internal/task", bytes.NewBufferString(fmt.Sprintf(`{"id": %q, "result": 4}`, third.Task.ID))))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// Следующая операция приходит, как только готова, без опроса
+	require.NoError(t, stream.Send(&taskrpc.AgentMessage{Result: &taskrpc.TaskResult{Id: first.Task.Id, Result: 2}, Demand: 1}))
+	require.NoError(t, stream.Send(&taskrpc.AgentMessage{Result: &taskrpc.TaskResult{Id: second.Task.Id, Result: 3}, Demand: 1}))
+	sum, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, "+", sum.Task.Operation)
+	assert.Equal(t, []float64{2, 3}, sum.Task.Args)
+	require.NoError(t, stream.Send(&taskrpc.AgentMessage{Result: &taskrpc.TaskResult{Id: sum.Task.Id, Result: 5}}))
+	last, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, []float64{5, 4}, last.Task.Args)
+	require.NoError(t, stream.Send(&taskrpc.AgentMessage{Result: &taskrpc.TaskResult{Id: last.Task.Id, Result: 9}}))
+
+	require.Eventually(t, func() bool {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/expressions/"+created.ID, nil))
+		var job application.Job
+		return json.NewDecoder(rec.Body).Decode(&job) == nil && job.Status == application.JobDone && job.Result.Result == 9
+	}, time.Second, 5*time.Millisecond)
+}
+
+func TestGRPC_Heartbeats(t *testing.T) {
+	app := application.New()
+	app.Config.HeartbeatInterval = 10 * time.Millisecond
+	stream := connectGRPC(t, app)
+	require.NoError(t, stream.Send(&taskrpc.AgentMessage{Demand: 1}))
+
+	// Оркестратор присылает сигналы и закрывает сессию молчащего агента
+	message, err := stream.Recv()
+	require.NoError(t, err)
+	require.NotNil(t, message.Heartbeat)
+	assert.NotZero(t, message.Heartbeat.SentAt)
+
+	for err == nil {
+		_, err = stream.Recv()
+	}
+	assert.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
+
+func TestRestoreJobs(t *testing.T) {
+	path := t.TempDir() + "/jobs.log"
+	start := func() (*application.Application, http.Handler) {
+		app := application.New()
+		app.Config.Distributed = true
+		app.Config.JobsFile = path
+		app.Logger = log.New(io.Discard, "", 0)
+		require.NoError(t, app.RestoreJobs())
+		return app, app.Handler()
+	}
+	do := func(handler http.Handler, method, target, body string, out any) int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, target, bytes.NewBufferString(body)))
+		if out != nil && rec.Code < 300 {
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(out))
+		}
+		return rec.Code
+	}
+	get := func(handler http.Handler, id string) application.Job {
+		var job application.Job
+		require.Equal(t, http.StatusOK, do(handler, http.MethodGet, "/api/v1/expressions/"+id, "", &job))
+		return job
+	}
+
+	// Первый запуск: локальное вычисление завершается, распределенное - наполовину
+	_, handler := start()
+	var local, distributed application.JobCreatedResponse
+	require.Equal(t, http.StatusCreated, do(handler, http.MethodPost, "/api/v1/expressions", `{"expression": "det([1, 2; 3, 4])"}`, &local))
+	require.Equal(t, http.StatusCreated, do(handler, http.MethodPost, "/api/v1/expressions", `{"expression": "(1 + 2) * (3 + 4)", "decimals": 1}`, &distributed))
+	require.Eventually(t, func() bool { return get(handler, local.ID).Status == application.JobDone }, time.Second, 5*time.Millisecond)
+
+	var first, second application.TaskEnvelope
+	require.Equal(t, http.StatusOK, do(handler, http.MethodGet, "/internal/task", "", &first))
+	require.Equal(t, http.StatusOK, do(handler, http.MethodGet, "/internal/task", "", &second))
+	require.Equal(t, http.StatusOK, do(handler, http.MethodPost, "/internal/task", fmt.Sprintf(`{"id": %q, "result": 3}`, first.Task.ID), nil))
+
+	// Перезапуск: выданная, но не вычисленная операция выдается снова
+	_, handler = start()
+	assert.Equal(t, -2.0, get(handler, local.ID).Result.Result)
+	assert.Equal(t, application.JobPending, get(handler, distributed.ID).Status)
+
+	var retried, product application.TaskEnvelope
+	require.Equal(t, http.StatusOK, do(handler, http.MethodGet, "/internal/task", "", &retried))
+	assert.Equal(t, []float64{3, 4}, retried.Task.Args)
+	assert.Equal(t, http.StatusNotFound, do(handler, http.MethodGet, "/internal/task", "", nil))
+	assert.Equal(t, http.StatusNotFound, do(handler, http.MethodPost, "/internal/task", fmt.Sprintf(`{"id": %q, "result": 3}`, first.Task.ID), nil))
+	require.Equal(t, http.StatusOK, do(handler, http.MethodPost, "/internal/task", fmt.Sprintf(`{"id": %q, "result": 7}`, retried.Task.ID), nil))
+	require.Equal(t, http.StatusOK, do(handler, http.MethodGet, "/internal/task", "", &product))
+	assert.Equal(t, []float64{3, 7}, product.Task.Args)
+	require.Equal(t, http.StatusOK, do(handler, http.MethodPost, "/internal/task", fmt.Sprintf(`{"id": %q, "result": 21}`, product.Task.ID), nil))
+
+	// Завершенные вычисления переживают перезапуск, журнал сжимается
+	_, handler = start()
+	job := get(handler, distributed.ID)
+	assert.Equal(t, application.JobDone, job.Status)
+	require.NotNil(t, job.Result)
+	assert.Equal(t, 21.0, job.Result.Result)
+	assert.Equal(t, "21.0", job.Result.Formatted)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, 2, bytes.Count(data, []byte("\n")))
+}
+
+func TestRestoreJobs_Log(t *testing.T) {
+	pending := `{"op":"create","job":{"id":"a1","expression":"2 + 2","status":"in_progress","created_at":"2026-10-17T09:00:00Z","updated_at":"2026-10-17T09:00:00Z"},"request":{"expression":"2 + 2"}}`
+	tests := []struct {
+		name        string
+		log         string
+		expectError bool
+		expectJobs  int
+	}{
+		{"missing file", "", false, 0},
+		{"pending job resumes", pending + "\n", false, 1},
+		{"torn last record", pending + "\n" + `{"op":"fin`, false, 1},
+		{"broken record", `{"op":"fin` + "\n" + pending + "\n", true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := t.TempDir() + "/jobs.log"
+			if tt.log != "" {
+				require.NoError(t, os.WriteFile(path, []byte(tt.log), 0o644))
+			}
+
+			app := application.New()
+			app.Config.JobsFile = path
+			app.Logger = log.New(io.Discard, "", 0)
+			err := app.RestoreJobs()
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			var list application.JobListResponse
+			rec := httptest.NewRecorder()
+			app.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/expressions", nil))
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&list))
+			require.Len(t, list.Expressions, tt.expectJobs)
+			if tt.expectJobs == 0 {
+				return
+			}
+
+			require.Eventually(t, func() bool {
+				rec := httptest.NewRecorder()
+				app.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/expressions/a1", nil))
+				var job application.Job
+				return json.NewDecoder(rec.Body).Decode(&job) == nil && job.Status == application.JobDone && job.Result.Result == 4
+			}, time.Second, 5*time.Millisecond)
+		})
+	}
+}
+
+func TestLeases(t *testing.T) {
+	app := application.New()
+	app.Config.Distributed = true
+	app.Config.LeaseDuration = 50 * time.Millisecond
+	app.Config.MaxTaskRetries = 1
+	app.Logger = log.New(io.Discard, "", 0)
+	handler := app.Handler()
+
+	do := func(method, target, body string, out any) int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(method, target, bytes.NewBufferString(body)))
+		if out != nil && rec.Code < 300 {
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(out))
+		}
+		return rec.Code
+	}
+	submit := func(expression string) string {
+		var created application.JobCreatedResponse
+		require.Equal(t, http.StatusCreated, do(http.MethodPost, "/api/v1/expressions", fmt.Sprintf(`{"expression": %q}`, expression), &created))
+		return created.ID
+	}
+	fetch := func() application.TaskResponse {
+		var envelope application.TaskEnvelope
+		require.Equal(t, http.StatusOK, do(http.MethodGet, "/internal/task", "", &envelope))
+		return envelope.Task
+	}
+	status := func(id string) application.Job {
+		var job application.Job
+		require.Equal(t, http.StatusOK, do(http.MethodGet, "/api/v1/expressions/"+id, "", &job))
+		return job
+	}
+
+	// Продление аренды не дает выдать операцию снова
+	{
+		id := submit("2 + 3")
+		task := fetch()
+		for range 6 {
+			time.Sleep(20 * time.Millisecond)
+			var extended application.LeaseResponse
+			require.Equal(t, http.StatusOK, do(http.MethodPost, "/internal/task/"+task.ID+"/heartbeat", "", &extended))
+			assert.True(t, extended.LeaseExpiresAt.After(time.Now()))
+		}
+		assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/internal/task", "", nil))
+
+		require.Equal(t, http.StatusOK, do(http.MethodPost, "/internal/task", fmt.Sprintf(`{"id": %q, "result": 5}`, task.ID), nil))
+		assert.Equal(t, application.JobDone, status(id).Status)
+		assert.Equal(t, http.StatusNotFound, do(http.MethodPost, "/internal/task/"+task.ID+"/heartbeat", "", nil))
+	}
+
+	// Операция с истекшей арендой выдается снова не больше MaxTaskRetries раз
+	{
+		id := submit("4 * 5")
+		first := fetch()
+
+		var second application.TaskEnvelope
+		require.Eventually(t, func() bool {
+			return do(http.MethodGet, "/internal/task", "", &second) == http.StatusOK
+		}, time.Second, 5*time.Millisecond)
+		assert.NotEqual(t, first.ID, second.Task.ID)
+		assert.Equal(t, first.Args, second.Task.Args)
+
+		// Результат потерявшего аренду агента не принимается
+		assert.Equal(t, http.StatusNotFound, do(http.MethodPost, "/internal/task", fmt.Sprintf(`{"id": %q, "result": 20}`, first.ID), nil))
+		assert.Equal(t, http.StatusNotFound, do(http.MethodPost, "/internal/task/"+first.ID+"/heartbeat", "", nil))
+
+		// Вторая аренда тоже истекает: операция считается отравленной
+		require.Eventually(t, func() bool { return status(id).Status == application.JobError }, time.Second, 5*time.Millisecond)
+		assert.Equal(t, &application.ErrorResponse{Error: "Task retry limit exceeded", Code: http.StatusUnprocessableEntity}, status(id).Error)
+		assert.Equal(t, http.StatusNotFound, do(http.MethodGet, "/internal/task", "", nil))
+	}
+}
+
+func TestLeases_GRPCSessionClosed(t *testing.T) {
+	app := application.New()
+	app.Config.Distributed = true
+	app.Config.LeaseDuration = time.Hour
+	app.Logger = log.New(io.Discard, "", 0)
+	handler := app.Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/expressions", bytes.NewBufferString(`{"expression": "2 ^ 10"}`)))
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	server := app.GRPCServer()
+	go server.Serve(listener)
+	defer server.Stop()
+	conn, err := grpc.NewClient(listener.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := taskrpc.NewOrchestratorClient(conn).Connect(ctx)
+	require.NoError(t, err)
+	require.NoError(t, stream.Send(&taskrpc.AgentMessage{Demand: 1}))
+	message, err := stream.Recv()
+	require.NoError(t, err)
+	require.NotNil(t, message.Task)
+
+	// Агент отключился: операция сразу выдается снова, не дожидаясь аренды
+	cancel()
+	var envelope application.TaskEnvelope
+	require.Eventually(t, func() bool {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/internal/task", nil))
+		return rec.Code == http.StatusOK && json.NewDecoder(rec.Body).Decode(&envelope) == nil
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, message.Task.Args, envelope.Task.Args)
+	assert.NotEqual(t, message.Task.Id, envelope.Task.ID)
+}
+
+func TestScheduler_Fairness(t *testing.T) {
+	app := application.New()
+	app.Config.BatchWorkers = 1
+	app.Logger = log.New(io.Discard, "", 0)
+	handler := app.Handler()
+
+	post := func(client, target, body string) <-chan int {
+		done := make(chan int, 1)
+		go func() {
+			req := httptest.NewRequest(http.MethodPost, target, bytes.NewBufferString(body))
+			req.Header.Set("X-Client-ID", client)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			done <- rec.Code
+		}()
+		return done
+	}
+	batch := func(n int) string {
+		items := make([]string, n)
+		for i := range items {
+			items[i] = `{"expression": "sum(sin(k), k, 1, 20000)"}`
+		}
+		return "[" + strings.Join(items, ",") + "]"
+	}
+	queued := func() int {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/queue", nil))
+		var stats application.QueueStats
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&stats))
+		return stats.Priorities[1].Depth
+	}
+
+	// Один клиент занимает единственный вычислитель большим пакетом
+	bulk := post("bulk", "/calculate/batch", batch(40))
+	require.Eventually(t, func() bool { return queued() > 30 }, time.Second, time.Millisecond)
+
+	// Интерактивный запрос и маленький пакет другого клиента не ждут весь пакет
+	interactive := post("ui", "/calculate", `{"expression": "2 + 2"}`)
+	small := post("other", "/calculate/batch", batch(2))
+	assert.Equal(t, http.StatusOK, <-interactive)
+	assert.Equal(t, http.StatusOK, <-small)
+	select {
+	case <-bulk:
+		t.Fatal("bulk batch finished before other clients")
+	default:
+	}
+	assert.Equal(t, http.StatusOK, <-bulk)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/queue", nil))
+	var stats application.QueueStats
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&stats))
+	assert.Equal(t, 1, stats.Workers)
+	assert.Equal(t, 0, stats.Busy)
+	require.Len(t, stats.Priorities, 3)
+	for i, priority := range []application.Priority{application.PriorityHigh, application.PriorityNormal, application.PriorityLow} {
+		assert.Equal(t, priority, stats.Priorities[i].Priority)
+		assert.Zero(t, stats.Priorities[i].Depth)
+	}
+	assert.Equal(t, int64(1), stats.Priorities[0].Started)
+	assert.Equal(t, int64(42), stats.Priorities[1].Started)
+	assert.Greater(t, stats.Priorities[1].MaxWaitMs, stats.Priorities[0].MaxWaitMs)
+	assert.LessOrEqual(t, stats.Priorities[1].AverageWaitMs, stats.Priorities[1].MaxWaitMs)
+}
+
+func TestScheduler_Priority(t *testing.T) {
+	app := application.New()
+	app.Logger = log.New(io.Discard, "", 0)
+	handler := app.Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/calculate", bytes.NewBufferString(`{"expression": "1 + 1", "priority": "urgent"}`)))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Invalid priority")
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/calculate/batch", bytes.NewBufferString(`[{"expression": "1 + 1", "priority": "low"}, {"expression": "2 + 2", "priority": "urgent"}]`)))
+	require.Equal(t, http.StatusOK, rec.Code)
+	var batch application.BatchResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&batch))
+	require.Len(t, batch.Results, 2)
+	assert.Equal(t, 2.0, batch.Results[0].Result)
+	assert.Equal(t, &application.ErrorResponse{Error: "Invalid priority", Code: http.StatusBadRequest}, batch.Results[1].Error)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/queue", nil))
+	var stats application.QueueStats
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&stats))
+	assert.Equal(t, int64(1), stats.Priorities[0].Started)
+	assert.Equal(t, int64(1), stats.Priorities[1].Started)
+	assert.Equal(t, int64(1), stats.Priorities[2].Started)
+}
+
+func TestScheduler_BulkPriorityCapped(t *testing.T) {
+	app := application.New()
+	app.Logger = log.New(io.Discard, "", 0)
+	handler := app.Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/calculate/batch", bytes.NewBufferString(`[{"expression": "1 + 1", "priority": "high"}, {"expression": "2 + 2", "priority": "high"}]`)))
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/calculate/stream", bytes.NewBufferString(`{"expression": "3 + 3", "priority": "high"}`)))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// Массовые вычисления не попадают в очередь интерактивных запросов
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/queue", nil))
+	var stats application.QueueStats
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&stats))
+	assert.Equal(t, int64(0), stats.Priorities[0].Started)
+	assert.Equal(t, int64(3), stats.Priorities[1].Started)
+}
+
+func TestScheduler_TaskPriority(t *testing.T) {
+	app := application.New()
+	app.Config.Distributed = true
+	app.Logger = log.New(io.Discard, "", 0)
+	handler := app.Handler()
+
+	for _, body := range []string{
+		`{"expression": "1 + 2", "priority": "low"}`,
+		`{"expression": "3 + 4"}`,
+		`{"expression": "5 + 6", "priority": "high"}`,
+		`{"expression": "7 + 8", "priority": "low"}`,
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/expressions", bytes.NewBufferString(body)))
+		require.Equal(t, http.StatusCreated, rec.Code)
+	}
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/queue", nil))
+	var stats application.QueueStats
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&stats))
+	assert.Equal(t, []int{0, 2, 2}, []int{stats.Priorities[0].Tasks, stats.Priorities[1].Tasks, stats.Priorities[2].Tasks})
+
+	// Операции выдаются агентам по приоритету, внутри приоритета - по очереди;
+	// high асинхронного вычисления понижается до normal
+	var args [][]float64
+	for range 4 {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/internal/task", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		var envelope application.TaskEnvelope
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&envelope))
+		args = append(args, envelope.Task.Args)
+	}
+	assert.Equal(t, [][]float64{{3, 4}, {5, 6}, {1, 2}, {7, 8}}, args)
+}
+
+func TestScheduler_RequeueKeepsPriority(t *testing.T) {
+	app := application.New()
+	app.Config.Distributed = true
+	app.Config.LeaseDuration = 50 * time.Millisecond
+	app.Logger = log.New(io.Discard, "", 0)
+	handler := app.Handler()
+
+	submit := func(body string) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/v1/expressions", bytes.NewBufferString(body)))
+		require.Equal(t, http.StatusCreated, rec.Code)
+	}
+	fetch := func() []float64 {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/internal/task", nil))
+		require.Equal(t, http.StatusOK, rec.Code)
+		var envelope application.TaskEnvelope
+		require.NoError(t, json.NewDecoder(rec.Body).Decode(&envelope))
+		return envelope.Task.Args
+	}
+
+	submit(`{"expression": "1 + 2", "priority": "low"}`)
+	assert.Equal(t, []float64{1, 2}, fetch())
+	submit(`{"expression": "3 + 4"}`)
+	submit(`{"expression": "5 + 6", "priority": "low"}`)
+
+	// Аренда истекла: операция возвращается в очередь
+	require.Eventually(t, func() bool {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/queue", nil))
+		var stats application.QueueStats
+		return json.NewDecoder(rec.Body).Decode(&stats) == nil && stats.Priorities[2].Tasks == 2
+	}, time.Second, 5*time.Millisecond)
+
+	// Повторная выдача - первой среди операций low, но после normal
+	assert.Equal(t, [][]float64{{3, 4}, {1, 2}, {5, 6}}, [][]float64{fetch(), fetch(), fetch()})
+}
+
+// sseEvent событие потока хода вычисления
+type sseEvent struct {
+	id       string
+	progress application.JobProgress
+}
+
+// readEvents подключается к потоку событий вычисления и возвращает канал его событий
+func readEvents(t *testing.T, url, lastEventID string) (*http.Response, <-chan sseEvent) {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	require.NoError(t, err)
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	t.Cleanup(func() { resp.Body.Close() })
+
+	events := make(chan sseEvent)
+	go func() {
+		defer close(events)
+		var event sseEvent
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			switch {
+			case strings.HasPrefix(line, "id: "):
+				event.id = strings.TrimPrefix(line, "id: ")
+			case strings.HasPrefix(line, "data: "):
+				if json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event.progress) != nil {
+					return
+				}
+			case line == "" && event.id != "":
+				events <- event
+				event = sseEvent{}
+			}
+		}
+	}()
+	return resp, events
+}
+
+func TestExpressionEvents(t *testing.T) {
+	app := application.New()
+	app.Config.Distributed = true
+	app.Logger = log.New(io.Discard, "", 0)
+	server := httptest.NewServer(app.Handler())
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/api/v1/expressions", "application/json", bytes.NewBufferString(`{"expression": "(1 + 2) * (3 + 4)"}`))
+	require.NoError(t, err)
+	var created application.JobCreatedResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+	url := server.URL + "/api/v1/expressions/" + created.ID + "/events"
+
+	stream, events := readEvents(t, url, "")
+	assert.Equal(t, "text/event-stream", stream.Header.Get("Content-Type"))
+	first := <-events
+	assert.Equal(t, "0", first.id)
+	assert.Equal(t, 0, first.progress.Done)
+	assert.Equal(t, 3, first.progress.Total)
+	assert.Zero(t, first.progress.Percent)
+
+	// Агент вычисляет операции, поток сообщает о каждой
+	go func() {
+		for range 3 {
+			var envelope application.TaskEnvelope
+			for {
+				resp, err := http.Get(server.URL + "/internal/task")
+				if err != nil {
+					return
+				}
+				if resp.StatusCode == http.StatusOK {
+					_ = json.NewDecoder(resp.Body).Decode(&envelope)
+					resp.Body.Close()
+					break
+				}
+				resp.Body.Close()
+				time.Sleep(time.Millisecond)
+			}
+			result := envelope.Task.Args[0] + envelope.Task.Args[1]
+			if envelope.Task.Operation == "*" {
+				result = envelope.Task.Args[0] * envelope.Task.Args[1]
+			}
+			resp, err := http.Post(server.URL+"/internal/task", "application/json", bytes.NewBufferString(fmt.Sprintf(`{"id": %q, "result": %g}`, envelope.Task.ID, result)))
+			if err != nil {
+				return
+			}
+			resp.Body.Close()
+		}
+	}()
+
+	var partial []application.PartialResult
+	var last sseEvent
+	var percents []float64
+	for event := range events {
+		partial = append(partial, event.progress.Partial...)
+		percents = append(percents, event.progress.Percent)
+		last = event
+	}
+	require.Len(t, partial, 3)
+	assert.ElementsMatch(t, []application.PartialResult{
+		{Operation: "+", Args: []float64{1, 2}, Result: 3},
+		{Operation: "+", Args: []float64{3, 4}, Result: 7},
+	}, partial[:2])
+	assert.Equal(t, application.PartialResult{Operation: "*", Args: []float64{3, 7}, Result: 21}, partial[2])
+	assert.IsNonDecreasing(t, percents)
+	assert.Equal(t, "3", last.id)
+	assert.Equal(t, application.JobDone, last.progress.Status)
+	assert.Equal(t, 3, last.progress.Done)
+	assert.Equal(t, 100.0, last.progress.Percent)
+	require.NotNil(t, last.progress.Result)
+	assert.Equal(t, 21.0, last.progress.Result.Result)
+
+	// После переподключения приходят только пропущенные результаты
+	_, events = readEvents(t, url, "1")
+	resumed := <-events
+	assert.Equal(t, "3", resumed.id)
+	assert.Equal(t, partial[1:], resumed.progress.Partial)
+	assert.Equal(t, application.JobDone, resumed.progress.Status)
+	_, open := <-events
+	assert.False(t, open)
+}
+
+func TestExpressionEvents_Local(t *testing.T) {
+	app := application.New()
+	app.Logger = log.New(io.Discard, "", 0)
+	server := httptest.NewServer(app.Handler())
+	defer server.Close()
+
+	resp, err := http.Post(server.URL+"/api/v1/expressions", "application/json", bytes.NewBufferString(`{"expression": "1 / 0"}`))
+	require.NoError(t, err)
+	var created application.JobCreatedResponse
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	resp.Body.Close()
+
+	_, events := readEvents(t, server.URL+"/api/v1/expressions/"+created.ID+"/events", "")
+	var last sseEvent
+	for event := range events {
+		assert.Empty(t, event.progress.Partial)
+		last = event
+	}
+	assert.Equal(t, application.JobError, last.progress.Status)
+	assert.Zero(t, last.progress.Total)
+	require.NotNil(t, last.progress.Error)
+	assert.Equal(t, http.StatusUnprocessableEntity, last.progress.Error.Code)
+
+	resp, err = http.Get(server.URL + "/api/v1/expressions/unknown/events")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
@@ -0,0 +1,70 @@
+package application
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// maxBatchSize наибольшее количество выражений в одном пакете
+const maxBatchSize = 100000
+
+// BatchResponse результаты пакета в порядке выражений запроса; ошибка
+// отдельного выражения записывается в поле error его результата
+type BatchResponse struct {
+	Results []Response `json:"results"`
+}
+
+// BatchHandler вычисляет массив запросов вида Request, в том числе с
+// переменными, параллельно в общей очереди сервера
+func (app *Application) BatchHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		app.SendError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	var items []Request
+	if err := json.NewDecoder(r.Body).Decode(&items); err != nil {
+		app.SendError(w, http.StatusBadRequest, "Invalid Request")
+		return
+	}
+	if len(items) > maxBatchSize {
+		app.SendError(w, http.StatusRequestEntityTooLarge, "Batch is too large")
+		return
+	}
+
+	// Выражения встают в общую очередь клиента; у каждого свой приоритет
+	results := make([]Response, len(items))
+	ctx := r.Context()
+	client := clientID(r)
+	var wg sync.WaitGroup
+	wg.Add(len(items))
+	for i := range items {
+		app.schedule(bulkPriority(items[i].Priority), client, func() {
+			defer wg.Done()
+			// Клиент мог отключиться: оставшиеся выражения не вычисляем
+			if ctx.Err() == nil {
+				results[i] = evaluateItem(items[i])
+			}
+		})
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		app.Logger.Printf("Batch cancelled: %v", ctx.Err())
+		return
+	}
+
+	app.Logger.Printf("Calculated batch of %d expressions", len(items))
+
+	app.SendJSON(w, http.StatusOK, BatchResponse{Results: results})
+}
+
+// evaluateItem вычисляет выражение пакета; ошибка становится частью результата
+func evaluateItem(req Request) Response {
+	response, errResponse := evaluate(req)
+	if errResponse != nil {
+		return Response{Error: errResponse}
+	}
+	return response
+}
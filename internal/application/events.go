@@ -0,0 +1,155 @@
+package application
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// sseKeepAlive как часто поток событий отправляет комментарий, чтобы прокси
+// не закрыли соединение без событий
+const sseKeepAlive = 15 * time.Second
+
+// PartialResult результат вычисленной операции распределенного вычисления
+type PartialResult struct {
+	Operation string    `json:"operation"`
+	Args      []float64 `json:"args"`
+	Result    float64   `json:"result"`
+}
+
+// MarshalJSON записывает особые значения строками, как в Response
+func (p PartialResult) MarshalJSON() ([]byte, error) {
+	type plain PartialResult
+	return json.Marshal(struct {
+		plain
+		Args   []jsonFloat `json:"args"`
+		Result jsonFloat   `json:"result"`
+	}{plain(p), toJSONFloatSlice(p.Args), jsonFloat(p.Result)})
+}
+
+// UnmarshalJSON читает результат, в котором особые значения записаны строками
+func (p *PartialResult) UnmarshalJSON(data []byte) error {
+	type plain PartialResult
+	decoded := struct {
+		*plain
+		Args   []jsonFloat `json:"args"`
+		Result jsonFloat   `json:"result"`
+	}{plain: (*plain)(p)}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	p.Args = make([]float64, len(decoded.Args))
+	for i, arg := range decoded.Args {
+		p.Args[i] = float64(arg)
+	}
+	p.Result = float64(decoded.Result)
+	return nil
+}
+
+// JobProgress событие хода вычисления
+type JobProgress struct {
+	ID      string          `json:"id"`
+	Status  JobStatus       `json:"status"`
+	Done    int             `json:"done"`              // Вычислено операций
+	Total   int             `json:"total"`             // Всего операций; 0 - выражение вычисляется целиком
+	Percent float64         `json:"percent"`           // Доля вычисленных операций, от 0 до 100
+	Partial []PartialResult `json:"partial,omitempty"` // Операции, вычисленные после предыдущего события
+	Result  *Response       `json:"result,omitempty"`
+	Error   *ErrorResponse  `json:"error,omitempty"`
+}
+
+// finished проверяет, что событие последнее
+func (p JobProgress) finished() bool {
+	return p.Status == JobDone || p.Status == JobError
+}
+
+// watch возвращает ход вычисления с результатами операций, начиная с номера
+// from, и канал, который закроется при следующем изменении
+func (s *jobStore) watch(id string, from int) (JobProgress, <-chan struct{}, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, exists := s.jobs[id]
+	if !exists {
+		return JobProgress{}, nil, false
+	}
+
+	progress := JobProgress{ID: job.ID, Status: job.Status, Result: job.Result, Error: job.Error}
+	if job.plan != nil {
+		progress.Done, progress.Total = job.plan.Progress()
+	}
+	switch {
+	case job.Status == JobDone:
+		progress.Percent = 100
+	case progress.Total > 0:
+		progress.Percent = float64(progress.Done) * 100 / float64(progress.Total)
+	}
+	if from < len(job.partial) {
+		progress.Partial = job.partial[from:]
+	}
+	return progress, job.changed, true
+}
+
+// ExpressionEventsHandler отправляет ход вычисления как Server-Sent Events:
+// событие progress сразу после подключения и после каждого изменения - начала
+// вычисления, результата операции, завершения. Последнее событие содержит
+// result или error, после него поток закрывается. Идентификатор события -
+// количество отправленных результатов операций: с заголовком Last-Event-ID
+// переподключившийся клиент получает только новые результаты.
+func (app *Application) ExpressionEventsHandler(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	sent, err := strconv.Atoi(r.Header.Get("Last-Event-ID"))
+	if err != nil || sent < 0 {
+		sent = 0
+	}
+
+	progress, changed, exists := app.jobs.watch(id, sent)
+	if !exists {
+		app.SendError(w, http.StatusNotFound, "Expression not found")
+		return
+	}
+
+	controller := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		data, err := json.Marshal(progress)
+		if err != nil {
+			app.Logger.Printf("Event encoding error: %v", err)
+			return
+		}
+		sent += len(progress.Partial)
+		if _, err := fmt.Fprintf(w, "id: %d\nevent: progress\ndata: %s\n\n", sent, data); err != nil {
+			return
+		}
+		if err := controller.Flush(); err != nil {
+			return
+		}
+		if progress.finished() {
+			return
+		}
+
+	wait:
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-changed:
+				break wait
+			case <-keepAlive.C:
+				if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+					return
+				}
+				if err := controller.Flush(); err != nil {
+					return
+				}
+			}
+		}
+		progress, changed, _ = app.jobs.watch(id, sent)
+	}
+}
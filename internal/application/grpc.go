@@ -0,0 +1,127 @@
+package application
+
+import (
+	"io"
+	"time"
+
+	"github.com/flexer2006/y.lms_sprint1_Calc/internal/taskrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// heartbeatsMissed сколько интервалов Heartbeat можно не получать сообщений
+// до закрытия сессии
+const heartbeatsMissed = 3
+
+// GRPCServer создает gRPC-сервер с сервисом выдачи операций агентам
+func (app *Application) GRPCServer() *grpc.Server {
+	server := grpc.NewServer()
+	taskrpc.RegisterOrchestratorServer(server, &taskServer{app: app})
+	return server
+}
+
+// taskServer выдает операции по gRPC: вместо опроса GET /internal/task
+// оркестратор сам отправляет операции агенту, как только они готовы
+type taskServer struct {
+	taskrpc.UnimplementedOrchestratorServer
+	app *Application
+}
+
+// agentMessage сообщение агента или ошибка чтения потока
+type agentMessage struct {
+	message *taskrpc.AgentMessage
+	err     error
+}
+
+// Connect ведет сессию агента: отправляет не больше операций, чем агент
+// запросил, принимает результаты и обменивается сигналами Heartbeat. Любое
+// сообщение агента продлевает аренды его операций; после закрытия сессии
+// операции сразу выдаются другим агентам.
+func (s *taskServer) Connect(stream taskrpc.Orchestrator_ConnectServer) error {
+	app := s.app
+	interval := max(app.Config.HeartbeatInterval, time.Millisecond)
+	app.jobs.leases.Do(app.runLeaseChecker)
+
+	session := newJobID()
+	defer app.jobs.releaseOwnerLeases(session, app.Config.MaxTaskRetries)
+
+	// Send нельзя вызывать одновременно, поэтому поток читает отдельная горутина,
+	// а пишет только этот цикл
+	messages := make(chan agentMessage)
+	go func() {
+		for {
+			message, err := stream.Recv()
+			select {
+			case messages <- agentMessage{message, err}:
+			case <-stream.Context().Done():
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	heartbeat := time.NewTicker(interval)
+	defer heartbeat.Stop()
+	lastSeen := time.Now()
+	demand := 0
+
+	for {
+		// Канал берется до проверки очереди, чтобы не пропустить новую операцию
+		added := app.jobs.taskAdded()
+		for demand > 0 {
+			issued, exists := app.jobs.nextTask(session, app.leaseDuration)
+			if !exists {
+				break
+			}
+			task := &taskrpc.Task{
+				Id:             issued.id(),
+				Operation:      issued.task.Operation,
+				Args:           issued.task.Args,
+				OperationTime:  app.operationTime(issued.task.Operation).Milliseconds(),
+				LeaseExpiresAt: issued.expires.UnixMilli(),
+			}
+			if err := stream.Send(&taskrpc.OrchestratorMessage{Task: task}); err != nil {
+				return err
+			}
+			demand--
+		}
+		if demand == 0 {
+			added = nil
+		}
+
+		select {
+		case <-stream.Context().Done():
+			return stream.Context().Err()
+
+		case received := <-messages:
+			if received.err == io.EOF {
+				return nil
+			}
+			if received.err != nil {
+				return received.err
+			}
+			lastSeen = time.Now()
+			app.jobs.extendOwnerLeases(session, app.Config.LeaseDuration)
+			demand += int(max(received.message.Demand, 0))
+			if result := received.message.Result; result != nil {
+				if !app.jobs.completeTask(TaskResult{ID: result.Id, Result: result.Result, Error: result.Error}) {
+					app.Logger.Printf("Task %s not found", result.Id)
+				}
+			}
+
+		case <-added:
+
+		case now := <-heartbeat.C:
+			if now.Sub(lastSeen) > heartbeatsMissed*interval {
+				return status.Error(codes.DeadlineExceeded, "agent missed heartbeats")
+			}
+			message := &taskrpc.OrchestratorMessage{Heartbeat: &taskrpc.Heartbeat{SentAt: now.UnixMilli()}}
+			if err := stream.Send(message); err != nil {
+				return err
+			}
+		}
+	}
+}
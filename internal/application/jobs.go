@@ -0,0 +1,206 @@
+package application
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/flexer2006/y.lms_sprint1_Calc/pkg/calculation"
+)
+
+// JobStatus состояние асинхронного вычисления
+type JobStatus string
+
+const (
+	JobPending    JobStatus = "pending"     // Ждет свободного вычислителя
+	JobInProgress JobStatus = "in_progress" // Вычисляется
+	JobDone       JobStatus = "done"        // Вычислено, результат в поле result
+	JobError      JobStatus = "error"       // Вычисление завершилось ошибкой
+)
+
+// Job асинхронное вычисление выражения
+type Job struct {
+	ID         string         `json:"id"`
+	Expression string         `json:"expression"`
+	Status     JobStatus      `json:"status"`
+	Result     *Response      `json:"result,omitempty"`
+	Error      *ErrorResponse `json:"error,omitempty"`
+	CreatedAt  time.Time      `json:"created_at"`
+	UpdatedAt  time.Time      `json:"updated_at"`
+
+	request Request           // Исходный запрос
+	client  string            // Клиент для справедливой очереди
+	plan    *calculation.Plan // Операции распределенного вычисления; nil для вычисления целиком
+	partial []PartialResult   // Результаты вычисленных операций по порядку
+	changed chan struct{}     // Закрывается при изменении вычисления
+}
+
+// JobCreatedResponse ответ на создание вычисления
+type JobCreatedResponse struct {
+	ID string `json:"id"`
+}
+
+// JobListResponse список вычислений в порядке создания
+type JobListResponse struct {
+	Expressions []Job `json:"expressions"`
+}
+
+// jobStore хранит вычисления и очередь операций для агентов; вычисления
+// целиком ставятся в общую очередь сервера
+type jobStore struct {
+	mu     sync.Mutex
+	jobs   map[string]*Job // Вычисления по идентификатору
+	order  []string        // Идентификаторы в порядке создания
+	leases sync.Once       // Проверка аренд запускается при первой выдаче операции
+
+	tasks  []queuedTask      // Готовые операции распределенных вычислений
+	issued map[string]*lease // Выданные агентам операции по идентификатору выдачи
+	added  chan struct{}     // Закрывается, когда в очереди появляются операции
+
+	log *jobLog // Журнал изменений; nil - вычисления хранятся только в памяти
+}
+
+// newJobStore создает пустое хранилище
+func newJobStore() *jobStore {
+	return &jobStore{jobs: make(map[string]*Job), issued: make(map[string]*lease), added: make(chan struct{})}
+}
+
+// newJob создает ожидающее вычисление запроса клиента
+func newJob(req Request, client string) *Job {
+	now := time.Now().UTC()
+	return &Job{
+		ID:         newJobID(),
+		Expression: req.Expression,
+		Status:     JobPending,
+		CreatedAt:  now,
+		UpdatedAt:  now,
+		request:    req,
+		client:     client,
+		changed:    make(chan struct{}),
+	}
+}
+
+// updated отмечает изменение вычисления и будит ждущих его событий
+func (s *jobStore) updated(job *Job) {
+	job.UpdatedAt = time.Now().UTC()
+	close(job.changed)
+	job.changed = make(chan struct{})
+}
+
+// add добавляет вычисление, которое вычисляется целиком
+func (s *jobStore) add(req Request, client string) Job {
+	job := newJob(req, client)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = job
+	s.order = append(s.order, job.ID)
+	s.logCreate(job)
+	return *job
+}
+
+// get возвращает копию вычисления
+func (s *jobStore) get(id string) (Job, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, exists := s.jobs[id]
+	if !exists {
+		return Job{}, false
+	}
+	return *job, true
+}
+
+// list возвращает копии всех вычислений в порядке создания
+func (s *jobStore) list() []Job {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	jobs := make([]Job, len(s.order))
+	for i, id := range s.order {
+		jobs[i] = *s.jobs[id]
+	}
+	return jobs
+}
+
+// start отмечает вычисление начатым
+func (s *jobStore) start(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job := s.jobs[id]
+	job.Status = JobInProgress
+	s.updated(job)
+}
+
+// finish записывает результат или ошибку вычисления
+func (s *jobStore) finish(id string, response Response, errResponse *ErrorResponse) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job := s.jobs[id]
+	if errResponse != nil {
+		job.Status, job.Error = JobError, errResponse
+	} else {
+		job.Status, job.Result = JobDone, &response
+	}
+	s.updated(job)
+	s.logFinish(job)
+}
+
+// newJobID создает случайный идентификатор вычисления
+func newJobID() string {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
+
+// runJob ставит вычисление в общую очередь с приоритетом запроса
+func (app *Application) runJob(job Job) {
+	app.schedule(bulkPriority(job.request.Priority), job.client, func() {
+		app.jobs.start(job.ID)
+		response, errResponse := evaluate(job.request)
+		app.jobs.finish(job.ID, response, errResponse)
+	})
+}
+
+// CreateExpressionHandler принимает выражение вида Request и сразу возвращает
+// идентификатор; выражение вычисляется в фоне
+func (app *Application) CreateExpressionHandler(w http.ResponseWriter, r *http.Request) {
+	var req Request
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		app.SendError(w, http.StatusBadRequest, "Invalid Request")
+		return
+	}
+	if req.Expression == "" {
+		app.SendError(w, http.StatusBadRequest, "Expression is required")
+		return
+	}
+
+	client := clientID(r)
+	job, planned := Job{}, false
+	if app.Config.Distributed && req.Mode == ModeDefault {
+		job, planned = app.jobs.addPlanned(req, client)
+	}
+	if !planned {
+		job = app.jobs.add(req, client)
+		app.runJob(job)
+	}
+	app.Logger.Printf("Expression %s accepted", job.ID)
+
+	app.SendJSON(w, http.StatusCreated, JobCreatedResponse{ID: job.ID})
+}
+
+// GetExpressionHandler возвращает состояние и результат вычисления
+func (app *Application) GetExpressionHandler(w http.ResponseWriter, r *http.Request) {
+	job, exists := app.jobs.get(r.PathValue("id"))
+	if !exists {
+		app.SendError(w, http.StatusNotFound, "Expression not found")
+		return
+	}
+	app.SendJSON(w, http.StatusOK, job)
+}
+
+// ListExpressionsHandler возвращает все вычисления
+func (app *Application) ListExpressionsHandler(w http.ResponseWriter, r *http.Request) {
+	app.SendJSON(w, http.StatusOK, JobListResponse{Expressions: app.jobs.list()})
+}
@@ -0,0 +1,414 @@
+package application
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/flexer2006/y.lms_sprint1_Calc/pkg/calculation"
+)
+
+// FunctionOperation ключ длительности вызова функции в Config.OperationTimes
+const FunctionOperation = "function"
+
+// operationTimeVariables переменные окружения с длительностью операций в миллисекундах
+var operationTimeVariables = map[string]string{
+	"+":               "TIME_ADDITION_MS",
+	"-":               "TIME_SUBTRACTION_MS",
+	"*":               "TIME_MULTIPLICATIONS_MS",
+	"/":               "TIME_DIVISIONS_MS",
+	"^":               "TIME_EXPONENTIATION_MS",
+	FunctionOperation: "TIME_FUNCTIONS_MS",
+}
+
+// operationTimesFromEnv читает длительности операций; по умолчанию операции мгновенны
+func operationTimesFromEnv() map[string]time.Duration {
+	times := make(map[string]time.Duration)
+	for operation, variable := range operationTimeVariables {
+		if ms, err := strconv.Atoi(os.Getenv(variable)); err == nil && ms > 0 {
+			times[operation] = time.Duration(ms) * time.Millisecond
+		}
+	}
+	return times
+}
+
+// TaskResponse операция, выданная агенту
+type TaskResponse struct {
+	ID            string    `json:"id"`
+	Operation     string    `json:"operation"`      // Оператор ("+", "-", "*", "/", "^", "+%", "-%") или имя функции
+	Args          []float64 `json:"args"`           // Значения операндов
+	OperationTime int64     `json:"operation_time"` // Сколько миллисекунд агент выполняет операцию
+	// LeaseExpiresAt до какого момента агент должен вернуть результат или продлить аренду
+	LeaseExpiresAt time.Time `json:"lease_expires_at"`
+}
+
+// LeaseResponse ответ на продление аренды операции
+type LeaseResponse struct {
+	LeaseExpiresAt time.Time `json:"lease_expires_at"`
+}
+
+// TaskEnvelope ответ GET /internal/task
+type TaskEnvelope struct {
+	Task TaskResponse `json:"task"`
+}
+
+// TaskResult результат операции от агента: число или текст ошибки вычисления.
+// Бесконечность и NaN записываются строками, как в Response.
+type TaskResult struct {
+	ID     string  `json:"id"`
+	Result float64 `json:"result"`
+	Error  string  `json:"error,omitempty"`
+}
+
+// MarshalJSON записывает особые значения результата строками
+func (r TaskResult) MarshalJSON() ([]byte, error) {
+	type plain TaskResult
+	return json.Marshal(struct {
+		plain
+		Result jsonFloat `json:"result"`
+	}{plain(r), jsonFloat(r.Result)})
+}
+
+// UnmarshalJSON читает результат, в котором особые значения записаны строками
+func (r *TaskResult) UnmarshalJSON(data []byte) error {
+	type plain TaskResult
+	decoded := struct {
+		*plain
+		Result jsonFloat `json:"result"`
+	}{plain: (*plain)(r)}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return err
+	}
+	r.Result = float64(decoded.Result)
+	return nil
+}
+
+// queuedTask операция распределенного вычисления
+type queuedTask struct {
+	jobID    string
+	task     calculation.Task
+	attempt  int      // Номер выдачи операции агенту, начиная с 1
+	priority Priority // Приоритет вычисления: операции выдаются по приоритету
+}
+
+// id возвращает идентификатор выдачи операции агенту. Номер выдачи входит
+// в идентификатор, поэтому результат агента, потерявшего аренду, не примется
+// вместо результата новой выдачи.
+func (q queuedTask) id() string {
+	return fmt.Sprintf("%s-%d-%d", q.jobID, q.task.ID, q.attempt)
+}
+
+// lease аренда операции агентом: если агент не вернул результат и не продлил
+// аренду до expires, операция выдается снова
+type lease struct {
+	queuedTask
+	owner   string // gRPC-сессия агента; пустой для HTTP
+	expires time.Time
+}
+
+// addPlanned разбивает выражение на операции для агентов. Если выражение
+// нельзя разбить (матрицы, даты), возвращает false, и его вычисляют целиком.
+func (s *jobStore) addPlanned(req Request, client string) (Job, bool) {
+	job := newJob(req, client)
+
+	calc, errResponse := prepare(req)
+	if errResponse == nil {
+		plan, err := calc.Plan(req.Expression)
+		if err == calculation.ErrNotDistributable {
+			return Job{}, false
+		}
+		if err != nil {
+			errResponse = calculationError(err)
+		}
+		job.plan = plan
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = job
+	s.order = append(s.order, job.ID)
+	s.logCreate(job)
+	if errResponse != nil {
+		s.failJob(job, errResponse)
+	} else {
+		s.advance(job)
+	}
+	return *job, true
+}
+
+// advance ставит в очередь готовые операции вычисления и завершает его,
+// если вычислены все операции
+func (s *jobStore) advance(job *Job) {
+	tasks := job.plan.Ready()
+	for _, task := range tasks {
+		s.enqueueTask(queuedTask{jobID: job.ID, task: task, priority: bulkPriority(job.request.Priority)}, false)
+	}
+	if len(tasks) > 0 {
+		close(s.added)
+		s.added = make(chan struct{})
+	}
+
+	value, done := job.plan.Result()
+	if !done {
+		return
+	}
+	response := Response{Result: value}
+	if errResponse := describeNumber(job.request, &response); errResponse != nil {
+		s.failJob(job, errResponse)
+		return
+	}
+	job.Status, job.Result = JobDone, &response
+	s.updated(job)
+	s.logFinish(job)
+}
+
+// enqueueTask ставит операцию в очередь после операций более высокого
+// приоритета: в конец операций того же приоритета, а если first - в их начало
+func (s *jobStore) enqueueTask(queued queuedTask, first bool) {
+	position := len(s.tasks)
+	for position > 0 {
+		previous := rank(s.tasks[position-1].priority)
+		if previous < rank(queued.priority) || previous == rank(queued.priority) && !first {
+			break
+		}
+		position--
+	}
+	s.tasks = slices.Insert(s.tasks, position, queued)
+}
+
+// taskCounts возвращает количество операций в очереди по приоритетам
+func (s *jobStore) taskCounts() map[Priority]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	counts := make(map[Priority]int)
+	for _, queued := range s.tasks {
+		counts[queued.priority]++
+	}
+	return counts
+}
+
+// failJob завершает вычисление ошибкой и убирает его операции из очереди
+func (s *jobStore) failJob(job *Job, errResponse *ErrorResponse) {
+	job.Status, job.Error = JobError, errResponse
+	s.updated(job)
+	s.logFinish(job)
+
+	tasks := s.tasks[:0]
+	for _, queued := range s.tasks {
+		if queued.jobID != job.ID {
+			tasks = append(tasks, queued)
+		}
+	}
+	s.tasks = tasks
+}
+
+// taskAdded возвращает канал, который закроется при появлении новых операций
+func (s *jobStore) taskAdded() <-chan struct{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.added
+}
+
+// nextTask выдает агенту первую готовую операцию в аренду; duration - срок
+// аренды операции
+func (s *jobStore) nextTask(owner string, duration func(operation string) time.Duration) (lease, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.tasks) == 0 {
+		return lease{}, false
+	}
+
+	queued := s.tasks[0]
+	s.tasks = s.tasks[1:]
+	queued.attempt++
+	issued := &lease{queuedTask: queued, owner: owner, expires: time.Now().Add(duration(queued.task.Operation))}
+	s.issued[queued.id()] = issued
+
+	job := s.jobs[queued.jobID]
+	if job.Status == JobPending {
+		job.Status = JobInProgress
+		s.updated(job)
+	}
+	return *issued, true
+}
+
+// extendLease продлевает аренду операции на duration от текущего момента;
+// false - аренда истекла или операция больше не нужна
+func (s *jobStore) extendLease(id string, duration time.Duration) (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	issued, exists := s.issued[id]
+	if !exists || s.jobs[issued.jobID].Status != JobInProgress {
+		return time.Time{}, false
+	}
+	issued.expires = later(issued.expires, time.Now().Add(duration))
+	return issued.expires, true
+}
+
+// extendOwnerLeases продлевает аренды всех операций gRPC-сессии
+func (s *jobStore) extendOwnerLeases(owner string, duration time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expires := time.Now().Add(duration)
+	for _, issued := range s.issued {
+		if issued.owner == owner {
+			issued.expires = later(issued.expires, expires)
+		}
+	}
+}
+
+// releaseOwnerLeases возвращает в очередь операции закрытой gRPC-сессии
+func (s *jobStore) releaseOwnerLeases(owner string, maxRetries int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, issued := range s.issued {
+		if issued.owner == owner {
+			s.requeue(issued, maxRetries)
+		}
+	}
+}
+
+// expireLeases возвращает в очередь операции с истекшей арендой
+func (s *jobStore) expireLeases(now time.Time, maxRetries int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, issued := range s.issued {
+		if now.After(issued.expires) {
+			s.requeue(issued, maxRetries)
+		}
+	}
+}
+
+// requeue снимает аренду и ставит операцию в начало операций ее приоритета.
+// Операция, которую не вернули maxRetries+1 раз подряд, считается отравленной:
+// скорее всего, она роняет агентов, и вычисление завершается ошибкой.
+func (s *jobStore) requeue(issued *lease, maxRetries int) {
+	delete(s.issued, issued.id())
+	job := s.jobs[issued.jobID]
+	if job.Status != JobInProgress {
+		return
+	}
+	if issued.attempt > maxRetries {
+		s.failJob(job, &ErrorResponse{Error: "Task retry limit exceeded", Code: http.StatusUnprocessableEntity})
+		return
+	}
+
+	s.enqueueTask(issued.queuedTask, true)
+	close(s.added)
+	s.added = make(chan struct{})
+}
+
+// later возвращает более поздний из моментов
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// completeTask записывает результат операции; false - операция не выдавалась
+func (s *jobStore) completeTask(result TaskResult) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	queued, exists := s.issued[result.ID]
+	if !exists {
+		return false
+	}
+	delete(s.issued, result.ID)
+
+	job := s.jobs[queued.jobID]
+	if job.Status != JobInProgress {
+		// Вычисление уже завершилось ошибкой другой операции
+		return true
+	}
+
+	err := calculation.TaskError(result.Error)
+	if result.Error == "" {
+		err = job.plan.Complete(queued.task.ID, result.Result)
+	}
+	if err != nil {
+		s.failJob(job, calculationError(err))
+		return true
+	}
+	s.logTask(job, queued.task.ID, result.Result)
+	job.partial = append(job.partial, PartialResult{Operation: queued.task.Operation, Args: queued.task.Args, Result: result.Result})
+	s.updated(job)
+	s.advance(job)
+	return true
+}
+
+// GetTaskHandler выдает агенту готовую операцию в аренду; 404, если операций нет
+func (app *Application) GetTaskHandler(w http.ResponseWriter, r *http.Request) {
+	app.jobs.leases.Do(app.runLeaseChecker)
+	issued, exists := app.jobs.nextTask("", app.leaseDuration)
+	if !exists {
+		app.SendJSON(w, http.StatusNotFound, map[string]string{"error": "No tasks"})
+		return
+	}
+
+	app.SendJSON(w, http.StatusOK, TaskEnvelope{Task: TaskResponse{
+		ID:             issued.id(),
+		Operation:      issued.task.Operation,
+		Args:           issued.task.Args,
+		OperationTime:  app.operationTime(issued.task.Operation).Milliseconds(),
+		LeaseExpiresAt: issued.expires.UTC(),
+	}})
+}
+
+// leaseDuration срок первой аренды операции: ее длительность и Config.LeaseDuration
+// на вычисление и передачу результата
+func (app *Application) leaseDuration(operation string) time.Duration {
+	return app.operationTime(operation) + app.Config.LeaseDuration
+}
+
+// runLeaseChecker запускает проверку истекших аренд
+func (app *Application) runLeaseChecker() {
+	go func() {
+		ticker := time.NewTicker(max(app.Config.LeaseDuration/4, time.Millisecond))
+		defer ticker.Stop()
+		for now := range ticker.C {
+			app.jobs.expireLeases(now, app.Config.MaxTaskRetries)
+		}
+	}()
+}
+
+// ExtendLeaseHandler продлевает аренду операции на Config.LeaseDuration.
+// 404 означает, что аренда истекла или вычисление завершилось, и агент
+// может прекратить вычислять операцию: ее результат не примут.
+func (app *Application) ExtendLeaseHandler(w http.ResponseWriter, r *http.Request) {
+	expires, exists := app.jobs.extendLease(r.PathValue("id"), app.Config.LeaseDuration)
+	if !exists {
+		app.SendError(w, http.StatusNotFound, "Task not found")
+		return
+	}
+	app.SendJSON(w, http.StatusOK, LeaseResponse{LeaseExpiresAt: expires.UTC()})
+}
+
+// operationTime возвращает длительность оператора или функции; наценка "+%" и
+// скидка "-%" длятся как сложение и вычитание
+func (app *Application) operationTime(operation string) time.Duration {
+	operation = strings.TrimSuffix(operation, "%")
+	if _, isOperator := operationTimeVariables[operation]; isOperator && operation != FunctionOperation {
+		return app.Config.OperationTimes[operation]
+	}
+	return app.Config.OperationTimes[FunctionOperation]
+}
+
+// PostTaskHandler принимает от агента результат операции
+func (app *Application) PostTaskHandler(w http.ResponseWriter, r *http.Request) {
+	var result TaskResult
+	if err := json.NewDecoder(r.Body).Decode(&result); err != nil || result.ID == "" {
+		app.SendError(w, http.StatusUnprocessableEntity, "Invalid task result")
+		return
+	}
+	if !app.jobs.completeTask(result) {
+		app.SendError(w, http.StatusNotFound, "Task not found")
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+}
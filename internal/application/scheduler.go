@@ -0,0 +1,269 @@
+package application
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Priority приоритет вычисления в очереди сервера
+type Priority string
+
+const (
+	PriorityHigh   Priority = "high"   // Интерактивные запросы; по умолчанию для /calculate и /solve, доступен только им
+	PriorityNormal Priority = "normal" // По умолчанию и наибольший для пакетов, потоков и асинхронных вычислений
+	PriorityLow    Priority = "low"    // Фоновые массовые вычисления
+)
+
+// priorities приоритеты в порядке обслуживания
+var priorities = []Priority{PriorityHigh, PriorityNormal, PriorityLow}
+
+// validPriority проверяет приоритет запроса; пустой - приоритет по умолчанию
+func validPriority(name string) bool {
+	return name == "" || rank(Priority(name)) >= 0
+}
+
+// priorityOf возвращает приоритет запроса или fallback, если он не задан или неизвестен
+func priorityOf(name string, fallback Priority) Priority {
+	if rank(Priority(name)) < 0 {
+		return fallback
+	}
+	return Priority(name)
+}
+
+// bulkPriority возвращает приоритет пакета, потока или асинхронного
+// вычисления: high оставлен интерактивным /calculate и /solve, поэтому
+// массовые вычисления не поднимаются выше normal
+func bulkPriority(name string) Priority {
+	priority := priorityOf(name, PriorityNormal)
+	if rank(priority) < rank(PriorityNormal) {
+		return PriorityNormal
+	}
+	return priority
+}
+
+// rank возвращает номер приоритета в порядке обслуживания или -1
+func rank(priority Priority) int {
+	for i, p := range priorities {
+		if p == priority {
+			return i
+		}
+	}
+	return -1
+}
+
+// clientID определяет клиента для справедливой очереди: заголовок X-Client-ID
+// или адрес, с которого пришел запрос
+func clientID(r *http.Request) string {
+	if id := r.Header.Get("X-Client-ID"); id != "" {
+		return id
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// scheduler делит Config.BatchWorkers вычислителей между всеми вычислениями
+// сервера. Приоритеты обслуживаются строго по порядку: пока ждет вычисление
+// high, normal не начинается. Внутри приоритета клиенты обслуживаются по
+// кругу по одному выражению, поэтому клиент с огромным пакетом не задерживает
+// остальных больше чем на одно выражение каждого.
+type scheduler struct {
+	mu      sync.Mutex
+	ready   *sync.Cond
+	levels  map[Priority]*fairQueue
+	busy    int       // Сколько вычислителей заняты
+	workers sync.Once // Вычислители запускаются при первом вычислении
+	count   int       // Сколько вычислителей запущено
+}
+
+// fairQueue очередь приоритета с отдельной очередью каждого клиента
+type fairQueue struct {
+	clients map[string][]*work // Ожидающие вычисления клиентов
+	ring    []string           // Клиенты с ожидающими вычислениями в порядке обслуживания
+	depth   int
+
+	started   int64         // Сколько вычислений начато
+	waitTotal time.Duration // Суммарное ожидание начатых вычислений
+	waitMax   time.Duration
+}
+
+// work ожидающее вычисление
+type work struct {
+	run      func()
+	enqueued time.Time
+}
+
+// newScheduler создает пустую очередь
+func newScheduler() *scheduler {
+	s := &scheduler{levels: make(map[Priority]*fairQueue)}
+	for _, priority := range priorities {
+		s.levels[priority] = &fairQueue{clients: make(map[string][]*work)}
+	}
+	s.ready = sync.NewCond(&s.mu)
+	return s
+}
+
+// submit ставит вычисление в очередь клиента
+func (s *scheduler) submit(priority Priority, client string, run func()) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	level := s.levels[priority]
+	if len(level.clients[client]) == 0 {
+		level.ring = append(level.ring, client)
+	}
+	level.clients[client] = append(level.clients[client], &work{run: run, enqueued: time.Now()})
+	level.depth++
+	s.ready.Signal()
+}
+
+// next ждет вычисление и отмечает вычислитель занятым
+func (s *scheduler) next() *work {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for {
+		for _, priority := range priorities {
+			if level := s.levels[priority]; level.depth > 0 {
+				s.busy++
+				return level.pop()
+			}
+		}
+		s.ready.Wait()
+	}
+}
+
+// pop берет вычисление первого в круге клиента и переводит клиента в конец круга
+func (q *fairQueue) pop() *work {
+	client := q.ring[0]
+	queue := q.clients[client]
+	next := queue[0]
+	queue[0] = nil
+
+	q.ring = q.ring[1:]
+	if len(queue) > 1 {
+		q.clients[client] = queue[1:]
+		q.ring = append(q.ring, client)
+	} else {
+		delete(q.clients, client)
+	}
+	q.depth--
+
+	wait := time.Since(next.enqueued)
+	q.started++
+	q.waitTotal += wait
+	q.waitMax = max(q.waitMax, wait)
+	return next
+}
+
+// done освобождает вычислитель
+func (s *scheduler) done() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.busy--
+}
+
+// start запускает count вычислителей
+func (s *scheduler) start(count int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.count = count
+	for range count {
+		go func() {
+			for {
+				s.next().run()
+				s.done()
+			}
+		}()
+	}
+}
+
+// schedule ставит вычисление в общую очередь
+func (app *Application) schedule(priority Priority, client string, run func()) {
+	app.scheduler.workers.Do(func() {
+		app.scheduler.start(max(app.Config.BatchWorkers, 1))
+	})
+	app.scheduler.submit(priority, client, run)
+}
+
+// scheduleWait вычисляет run в общей очереди и ждет его. Если клиент ушел
+// раньше, чем вычисление началось, оно не выполняется; false - клиент ушел.
+func (app *Application) scheduleWait(ctx context.Context, priority Priority, client string, run func()) bool {
+	done := make(chan struct{})
+	app.schedule(priority, client, func() {
+		defer close(done)
+		if ctx.Err() == nil {
+			run()
+		}
+	})
+	select {
+	case <-done:
+		return ctx.Err() == nil
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// QueueStats состояние очереди вычислений
+type QueueStats struct {
+	Workers    int             `json:"workers"` // Сколько вычислителей запущено
+	Busy       int             `json:"busy"`    // Сколько из них заняты
+	Priorities []PriorityStats `json:"priorities"`
+}
+
+// PriorityStats очередь одного приоритета. Время ожидания - от постановки в
+// очередь до начала вычисления, в миллисекундах, с запуска сервера.
+type PriorityStats struct {
+	Priority      Priority `json:"priority"`
+	Depth         int      `json:"depth"`           // Вычисления, ждущие вычислителя
+	Clients       int      `json:"clients"`         // Клиенты с ожидающими вычислениями
+	Tasks         int      `json:"tasks"`           // Операции, ждущие агента
+	Started       int64    `json:"started"`         // Начатые вычисления
+	AverageWaitMs float64  `json:"average_wait_ms"` // Среднее ожидание начатых
+	MaxWaitMs     float64  `json:"max_wait_ms"`     // Наибольшее ожидание начатых
+	OldestWaitMs  float64  `json:"oldest_wait_ms"`  // Сколько уже ждет самое старое из ожидающих
+}
+
+// stats возвращает состояние очереди; операции агентов считает jobStore
+func (s *scheduler) stats() QueueStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats := QueueStats{Workers: s.count, Busy: s.busy, Priorities: make([]PriorityStats, 0, len(priorities))}
+	now := time.Now()
+	for _, priority := range priorities {
+		level := s.levels[priority]
+		item := PriorityStats{
+			Priority:  priority,
+			Depth:     level.depth,
+			Clients:   len(level.ring),
+			Started:   level.started,
+			MaxWaitMs: milliseconds(level.waitMax),
+		}
+		if level.started > 0 {
+			item.AverageWaitMs = milliseconds(level.waitTotal / time.Duration(level.started))
+		}
+		for _, queue := range level.clients {
+			item.OldestWaitMs = max(item.OldestWaitMs, milliseconds(now.Sub(queue[0].enqueued)))
+		}
+		stats.Priorities = append(stats.Priorities, item)
+	}
+	return stats
+}
+
+// milliseconds переводит длительность в миллисекунды
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// QueueStatsHandler возвращает глубину очереди и время ожидания по приоритетам
+func (app *Application) QueueStatsHandler(w http.ResponseWriter, r *http.Request) {
+	stats := app.scheduler.stats()
+	tasks := app.jobs.taskCounts()
+	for i := range stats.Priorities {
+		stats.Priorities[i].Tasks = tasks[stats.Priorities[i].Priority]
+	}
+	app.SendJSON(w, http.StatusOK, stats)
+}
@@ -0,0 +1,100 @@
+package application
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// maxStreamLine наибольшая длина строки потока; более длинная строка завершает поток
+const maxStreamLine = 1 << 20
+
+// StreamHandler читает из тела запроса NDJSON - по запросу вида Request в строке -
+// и сразу отправляет результаты NDJSON в том же порядке. Вход не накапливается:
+// одновременно вычисляется не больше Config.BatchWorkers выражений, поэтому
+// память не зависит от размера входа. Строка с неправильным JSON получает
+// результат с ошибкой, пустые строки пропускаются.
+func (app *Application) StreamHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		app.SendError(w, http.StatusMethodNotAllowed, "Method Not Allowed")
+		return
+	}
+
+	// Результаты пишутся до того, как дочитан запрос; для HTTP/2 это не требуется
+	controller := http.NewResponseController(w)
+	_ = controller.EnableFullDuplex()
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.WriteHeader(http.StatusOK)
+
+	// Очередь результатов в порядке строк; ее емкость ограничивает число
+	// одновременно вычисляемых выражений
+	pending := make(chan chan Response, max(app.Config.BatchWorkers, 1))
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+	client := clientID(r)
+
+	go func() {
+		defer close(pending)
+
+		scanner := bufio.NewScanner(r.Body)
+		scanner.Buffer(make([]byte, 0, 64*1024), maxStreamLine)
+		for scanner.Scan() {
+			line := scanner.Bytes()
+			if len(line) == 0 {
+				continue
+			}
+
+			result := make(chan Response, 1)
+			select {
+			case pending <- result:
+			case <-ctx.Done():
+				return
+			}
+
+			var req Request
+			if err := json.Unmarshal(line, &req); err != nil {
+				result <- Response{Error: &ErrorResponse{Error: "Invalid Request", Code: http.StatusBadRequest}}
+				continue
+			}
+			app.schedule(bulkPriority(req.Priority), client, func() {
+				if ctx.Err() != nil {
+					result <- Response{}
+					return
+				}
+				result <- evaluateItem(req)
+			})
+		}
+
+		if err := scanner.Err(); err != nil {
+			app.Logger.Printf("Stream read error: %v", err)
+			result := make(chan Response, 1)
+			result <- Response{Error: &ErrorResponse{Error: "Invalid Request", Code: http.StatusBadRequest}}
+			select {
+			case pending <- result:
+			case <-ctx.Done():
+			}
+		}
+	}()
+
+	encoder := json.NewEncoder(w)
+	count := 0
+	for result := range pending {
+		if err := encoder.Encode(<-result); err != nil {
+			app.Logger.Printf("Stream write error: %v", err)
+			// Тело запроса нельзя читать после выхода из обработчика: ждем чтение
+			cancel()
+			for range pending {
+			}
+			return
+		}
+		// Отправляем накопленное, если следующих результатов в очереди нет
+		if len(pending) == 0 {
+			_ = controller.Flush()
+		}
+		count++
+	}
+
+	app.Logger.Printf("Streamed %d results", count)
+}
@@ -0,0 +1,285 @@
+package application
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"slices"
+
+	"github.com/flexer2006/y.lms_sprint1_Calc/pkg/calculation"
+)
+
+// maxLogLine наибольшая длина записи журнала вычислений
+const maxLogLine = 64 << 20
+
+// Виды записей журнала
+const (
+	logCreate = "create" // Принято вычисление
+	logFinish = "finish" // Вычисление завершено: состояние в поле job
+	logTask   = "task"   // Получен результат операции распределенного вычисления
+)
+
+// logRecord запись журнала вычислений
+type logRecord struct {
+	Op          string    `json:"op"`
+	Job         *Job      `json:"job,omitempty"`
+	Request     *Request  `json:"request,omitempty"`
+	Distributed bool      `json:"distributed,omitempty"` // Вычисление разбито на операции
+	Client      string    `json:"client,omitempty"`      // Клиент для справедливой очереди
+	ID          string    `json:"id,omitempty"`          // Вычисление, к которому относится операция
+	Task        int       `json:"task,omitempty"`        // Номер операции в плане
+	Result      jsonFloat `json:"result,omitempty"`      // Результат операции от агента
+}
+
+// jobLog журнал упреждающей записи: каждое изменение вычислений дописывается
+// в файл строкой JSON и сбрасывается на диск до ответа клиенту
+type jobLog struct {
+	file   *os.File
+	logger *log.Logger
+}
+
+// append дописывает запись; без журнала ничего не делает
+func (l *jobLog) append(record logRecord) {
+	if l == nil {
+		return
+	}
+	data, err := json.Marshal(record)
+	if err == nil {
+		_, err = l.file.Write(append(data, '\n'))
+	}
+	if err == nil {
+		err = l.file.Sync()
+	}
+	if err != nil {
+		l.logger.Printf("Jobs log write error: %v", err)
+	}
+}
+
+// logCreate записывает принятое вычисление
+func (s *jobStore) logCreate(job *Job) {
+	s.log.append(logRecord{Op: logCreate, Job: job, Request: &job.request, Distributed: job.plan != nil, Client: job.client})
+}
+
+// logFinish записывает завершенное вычисление
+func (s *jobStore) logFinish(job *Job) {
+	s.log.append(logRecord{Op: logFinish, Job: job})
+}
+
+// logTask записывает результат операции, принятый планом
+func (s *jobStore) logTask(job *Job, task int, result float64) {
+	s.log.append(logRecord{Op: logTask, ID: job.ID, Task: task, Result: jsonFloat(result)})
+}
+
+// RestoreJobs восстанавливает вычисления из журнала Config.JobsFile и затем
+// записывает в него все изменения. Незавершенные вычисления продолжаются:
+// ожидающие снова ставятся в очередь, распределенные повторно выдают агентам
+// операции, результатов которых нет в журнале. Журнал при этом сжимается до
+// текущего состояния. Вызывается до запуска сервера; без Config.JobsFile
+// вычисления хранятся только в памяти.
+func (app *Application) RestoreJobs() error {
+	path := app.Config.JobsFile
+	if path == "" {
+		return nil
+	}
+	records, err := readJobLog(path)
+	if err != nil {
+		return err
+	}
+
+	s := app.jobs
+	s.mu.Lock()
+	results, pending := s.replay(records)
+	jobLog, err := writeJobLog(path, s.snapshot(results), app.Logger)
+	if err == nil {
+		s.log = jobLog
+	}
+	restored := len(s.order)
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	for _, job := range pending {
+		app.runJob(job)
+	}
+	app.Logger.Printf("Restored %d expressions from %s", restored, path)
+	return nil
+}
+
+// readJobLog читает записи журнала. Недописанная последняя строка - след
+// аварийной остановки - отбрасывается; испорченная строка в середине - ошибка.
+func readJobLog(path string) ([]logRecord, error) {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var records []logRecord
+	broken := 0
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLogLine)
+	for line := 1; scanner.Scan(); line++ {
+		if broken > 0 {
+			return nil, fmt.Errorf("jobs log %s: invalid record at line %d", path, broken)
+		}
+		var record logRecord
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil || record.Op == "" {
+			broken = line
+			continue
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("jobs log %s: %w", path, err)
+	}
+	return records, nil
+}
+
+// replay восстанавливает вычисления по записям журнала и продолжает
+// распределенные. Возвращает результаты операций незавершенных распределенных
+// вычислений, чтобы сохранить их в сжатом журнале, и незавершенные вычисления,
+// которые нужно снова поставить в очередь целиком.
+func (s *jobStore) replay(records []logRecord) (map[string][]logRecord, []Job) {
+	results := make(map[string][]logRecord)
+	distributed := make(map[string]bool)
+	for _, record := range records {
+		switch record.Op {
+		case logCreate:
+			if record.Job == nil || record.Request == nil || s.jobs[record.Job.ID] != nil {
+				continue
+			}
+			job := *record.Job
+			job.request, job.client = *record.Request, record.Client
+			job.changed = make(chan struct{})
+			s.jobs[job.ID] = &job
+			s.order = append(s.order, job.ID)
+			distributed[job.ID] = record.Distributed
+
+		case logFinish:
+			if record.Job == nil {
+				continue
+			}
+			if job, exists := s.jobs[record.Job.ID]; exists {
+				job.Status, job.Result, job.Error = record.Job.Status, record.Job.Result, record.Job.Error
+				job.UpdatedAt = record.Job.UpdatedAt
+			}
+
+		case logTask:
+			results[record.ID] = append(results[record.ID], record)
+		}
+	}
+
+	var pending []Job
+	for _, id := range s.order {
+		job := s.jobs[id]
+		if job.Status == JobDone || job.Status == JobError {
+			delete(results, id)
+			continue
+		}
+		job.Status = JobPending
+		if distributed[id] {
+			s.resumePlanned(job, results[id])
+		} else {
+			pending = append(pending, *job)
+		}
+	}
+	return results, pending
+}
+
+// resumePlanned заново разбивает выражение и применяет к плану сохраненные
+// результаты операций. Операции, выданные агентам до остановки, снова
+// ставятся в очередь.
+func (s *jobStore) resumePlanned(job *Job, results []logRecord) {
+	calc, errResponse := prepare(job.request)
+	if errResponse != nil {
+		s.failJob(job, errResponse)
+		return
+	}
+	plan, err := calc.Plan(job.request.Expression)
+	if err != nil {
+		s.failJob(job, calculationError(err))
+		return
+	}
+	job.plan = plan
+
+	outstanding := make(map[int]calculation.Task)
+	issue := func() {
+		for _, task := range plan.Ready() {
+			outstanding[task.ID] = task
+		}
+	}
+	issue()
+	for _, result := range results {
+		task, exists := outstanding[result.Task]
+		if exists && plan.Complete(result.Task, float64(result.Result)) == nil {
+			job.partial = append(job.partial, PartialResult{Operation: task.Operation, Args: task.Args, Result: float64(result.Result)})
+			delete(outstanding, result.Task)
+			issue()
+		}
+	}
+
+	for _, id := range slices.Sorted(maps.Keys(outstanding)) {
+		s.enqueueTask(queuedTask{jobID: job.ID, task: outstanding[id], priority: bulkPriority(job.request.Priority)}, false)
+	}
+	s.advance(job)
+}
+
+// snapshot возвращает записи, из которых восстанавливается текущее состояние
+func (s *jobStore) snapshot(results map[string][]logRecord) []logRecord {
+	var records []logRecord
+	for _, id := range s.order {
+		job := s.jobs[id]
+		records = append(records, logRecord{Op: logCreate, Job: job, Request: &job.request, Distributed: job.plan != nil, Client: job.client})
+		if job.Status != JobDone && job.Status != JobError {
+			records = append(records, results[id]...)
+		}
+	}
+	return records
+}
+
+// writeJobLog атомарно заменяет журнал записями и открывает его для дописывания
+func writeJobLog(path string, records []logRecord, logger *log.Logger) (*jobLog, error) {
+	temporary := path + ".tmp"
+	file, err := os.Create(temporary)
+	if err != nil {
+		return nil, err
+	}
+
+	writer := bufio.NewWriter(file)
+	encoder := json.NewEncoder(writer)
+	for _, record := range records {
+		if err = encoder.Encode(record); err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = writer.Flush()
+	}
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(temporary, path)
+	}
+	if err != nil {
+		os.Remove(temporary)
+		return nil, err
+	}
+
+	file, err = os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	return &jobLog{file: file, logger: logger}, nil
+}
@@ -0,0 +1,128 @@
+// Контракт между оркестратором и агентами распределенного вычисления.
+// Go-код генерируется в internal/taskrpc: go generate ./internal/taskrpc
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: orchestrator.proto
+
+package taskrpc
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Orchestrator_Connect_FullMethodName = "/calculator.v1.Orchestrator/Connect"
+)
+
+// OrchestratorClient is the client API for Orchestrator service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type OrchestratorClient interface {
+	// Connect - сессия агента. Агент сообщает, сколько операций готов принять,
+	// оркестратор присылает операции по мере готовности, агент возвращает
+	// результаты в том же потоке. Обе стороны периодически отправляют Heartbeat;
+	// сессия без сообщений дольше трех интервалов закрывается, а операции
+	// агента выдаются другим агентам.
+	Connect(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[AgentMessage, OrchestratorMessage], error)
+}
+
+type orchestratorClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewOrchestratorClient(cc grpc.ClientConnInterface) OrchestratorClient {
+	return &orchestratorClient{cc}
+}
+
+func (c *orchestratorClient) Connect(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[AgentMessage, OrchestratorMessage], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Orchestrator_ServiceDesc.Streams[0], Orchestrator_Connect_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[AgentMessage, OrchestratorMessage]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Orchestrator_ConnectClient = grpc.BidiStreamingClient[AgentMessage, OrchestratorMessage]
+
+// OrchestratorServer is the server API for Orchestrator service.
+// All implementations must embed UnimplementedOrchestratorServer
+// for forward compatibility.
+type OrchestratorServer interface {
+	// Connect - сессия агента. Агент сообщает, сколько операций готов принять,
+	// оркестратор присылает операции по мере готовности, агент возвращает
+	// результаты в том же потоке. Обе стороны периодически отправляют Heartbeat;
+	// сессия без сообщений дольше трех интервалов закрывается, а операции
+	// агента выдаются другим агентам.
+	Connect(grpc.BidiStreamingServer[AgentMessage, OrchestratorMessage]) error
+	mustEmbedUnimplementedOrchestratorServer()
+}
+
+// UnimplementedOrchestratorServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedOrchestratorServer struct{}
+
+func (UnimplementedOrchestratorServer) Connect(grpc.BidiStreamingServer[AgentMessage, OrchestratorMessage]) error {
+	return status.Errorf(codes.Unimplemented, "method Connect not implemented")
+}
+func (UnimplementedOrchestratorServer) mustEmbedUnimplementedOrchestratorServer() {}
+func (UnimplementedOrchestratorServer) testEmbeddedByValue()                      {}
+
+// UnsafeOrchestratorServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to OrchestratorServer will
+// result in compilation errors.
+type UnsafeOrchestratorServer interface {
+	mustEmbedUnimplementedOrchestratorServer()
+}
+
+func RegisterOrchestratorServer(s grpc.ServiceRegistrar, srv OrchestratorServer) {
+	// If the following call pancis, it indicates UnimplementedOrchestratorServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Orchestrator_ServiceDesc, srv)
+}
+
+func _Orchestrator_Connect_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(OrchestratorServer).Connect(&grpc.GenericServerStream[AgentMessage, OrchestratorMessage]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Orchestrator_ConnectServer = grpc.BidiStreamingServer[AgentMessage, OrchestratorMessage]
+
+// Orchestrator_ServiceDesc is the grpc.ServiceDesc for Orchestrator service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Orchestrator_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "calculator.v1.Orchestrator",
+	HandlerType: (*OrchestratorServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Connect",
+			Handler:       _Orchestrator_Connect_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "orchestrator.proto",
+}
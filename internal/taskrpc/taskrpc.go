@@ -0,0 +1,6 @@
+// Package taskrpc - gRPC-транспорт между оркестратором и агентами по контракту
+// api/orchestrator.proto. Сообщения и сервис генерируются protoc-gen-go и
+// protoc-gen-go-grpc; после изменения контракта: go generate ./internal/taskrpc
+package taskrpc
+
+//go:generate protoc -I ../../api --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative orchestrator.proto
@@ -0,0 +1,77 @@
+package taskrpc_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/flexer2006/y.lms_sprint1_Calc/internal/taskrpc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+)
+
+func TestMessages_RoundTrip(t *testing.T) {
+	tests := []struct {
+		name    string
+		message proto.Message
+	}{
+		{"task", &taskrpc.OrchestratorMessage{Task: &taskrpc.Task{Id: "a-1", Operation: "max", Args: []float64{1.5, -2, 0}, OperationTime: 2000, LeaseExpiresAt: 1792227630000}}},
+		{"orchestrator heartbeat", &taskrpc.OrchestratorMessage{Heartbeat: &taskrpc.Heartbeat{SentAt: 1792227600000}}},
+		{"result with demand", &taskrpc.AgentMessage{Demand: 1, Result: &taskrpc.TaskResult{Id: "a-1", Result: math.Inf(-1)}}},
+		{"negative zero", &taskrpc.AgentMessage{Result: &taskrpc.TaskResult{Id: "a-2", Result: math.Copysign(0, -1)}}},
+		{"error", &taskrpc.AgentMessage{Result: &taskrpc.TaskResult{Id: "a-3", Error: "division by zero"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := proto.Marshal(tt.message)
+			require.NoError(t, err)
+
+			decoded := tt.message.ProtoReflect().New().Interface()
+			require.NoError(t, proto.Unmarshal(data, decoded))
+			assert.True(t, proto.Equal(tt.message, decoded), "%v != %v", tt.message, decoded)
+		})
+	}
+}
+
+func TestMessages_Wire(t *testing.T) {
+	// Task{id: "x", args: [2]} в формате protobuf; args упакованы
+	expected := protowire.AppendTag(nil, 1, protowire.BytesType)
+	expected = protowire.AppendString(expected, "x")
+	expected = protowire.AppendTag(expected, 3, protowire.BytesType)
+	expected = protowire.AppendVarint(expected, 8)
+	expected = protowire.AppendFixed64(expected, math.Float64bits(2))
+	wrapped := protowire.AppendTag(nil, 1, protowire.BytesType)
+	wrapped = protowire.AppendBytes(wrapped, expected)
+
+	data, err := proto.Marshal(&taskrpc.OrchestratorMessage{Task: &taskrpc.Task{Id: "x", Args: []float64{2}}})
+	require.NoError(t, err)
+	assert.Equal(t, wrapped, data)
+}
+
+func TestService_Contract(t *testing.T) {
+	// Сгенерированный код соответствует api/orchestrator.proto
+	file := taskrpc.File_orchestrator_proto
+	require.Equal(t, 1, file.Services().Len())
+	service := file.Services().Get(0)
+	assert.Equal(t, protoreflect.FullName("calculator.v1.Orchestrator"), service.FullName())
+	assert.Equal(t, string(service.FullName()), taskrpc.Orchestrator_ServiceDesc.ServiceName)
+
+	connect := service.Methods().ByName("Connect")
+	require.NotNil(t, connect)
+	assert.True(t, connect.IsStreamingClient())
+	assert.True(t, connect.IsStreamingServer())
+	assert.Equal(t, protoreflect.FullName("calculator.v1.AgentMessage"), connect.Input().FullName())
+	assert.Equal(t, protoreflect.FullName("calculator.v1.OrchestratorMessage"), connect.Output().FullName())
+	assert.Equal(t, "/calculator.v1.Orchestrator/Connect", taskrpc.Orchestrator_Connect_FullMethodName)
+
+	task := (&taskrpc.Task{}).ProtoReflect().Descriptor().Fields()
+	for name, number := range map[protoreflect.Name]protoreflect.FieldNumber{
+		"id": 1, "operation": 2, "args": 3, "operation_time": 4, "lease_expires_at": 5,
+	} {
+		require.NotNil(t, task.ByName(name), name)
+		assert.Equal(t, number, task.ByName(name).Number(), name)
+	}
+}
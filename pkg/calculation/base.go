@@ -0,0 +1,137 @@
+package calculation
+
+import (
+	"math"
+	"math/big"
+	"strings"
+)
+
+// Допустимые основания систем счисления
+const (
+	MinBase = 2
+	MaxBase = 36
+)
+
+// baseDigits цифры систем счисления с основанием до 36
+const baseDigits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+// FormatBase записывает число в системе счисления с основанием от 2 до 36:
+// 255.5 по основанию 16 - "ff.8". Целая часть записывается точно, дробная -
+// с точностью float64 с округлением последней цифры: 1/3 по основанию 3 - "0.1".
+func FormatBase(x float64, base int) (string, error) {
+	if base < MinBase || base > MaxBase {
+		return "", ErrInvalidBase
+	}
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return "", ErrInvalidArgument
+	}
+
+	// Точность с запасом: дробная часть float64 занимает не более 1074 двоичных знаков
+	value := new(big.Float).SetPrec(2048).SetFloat64(math.Abs(x))
+	integer, _ := value.Int(nil)
+	fraction := value.Sub(value, new(big.Float).SetInt(integer))
+
+	// Нулевая целая часть не записывается в digits, чтобы перенос при округлении
+	// только добавлял цифру слева
+	var digits []byte
+	if integer.Sign() != 0 {
+		digits = []byte(integer.Text(base))
+	}
+	point := len(digits)
+
+	// Для оснований 2, 4, 8, 16 и 32 дробь записывается точно; для остальных -
+	// не больше цифр, чем вмещают 53 двоичных знака, считая от первой ненулевой
+	precision := math.MaxInt
+	if base&(base-1) != 0 {
+		precision = int(53 / math.Log2(float64(base)))
+	}
+	significant := point
+
+	scale := new(big.Float).SetInt64(int64(base))
+	for fraction.Sign() != 0 && significant < precision {
+		fraction.Mul(fraction, scale)
+		digit, _ := fraction.Int64()
+		fraction.Sub(fraction, new(big.Float).SetInt64(digit))
+		digits = append(digits, baseDigits[digit])
+		if significant > 0 || digit != 0 {
+			significant++
+		}
+	}
+
+	// Округляем последнюю цифру по следующей
+	if fraction.Sign() != 0 {
+		fraction.Mul(fraction, scale)
+		if next, _ := fraction.Int64(); 2*next >= int64(base) {
+			digits, point = incrementDigits(digits, point, base)
+		}
+	}
+
+	text := string(digits[:point])
+	if text == "" {
+		text = "0"
+	}
+	if decimals := strings.TrimRight(string(digits[point:]), "0"); decimals != "" {
+		text += "." + decimals
+	}
+	if x < 0 && strings.Trim(text, "0.") != "" {
+		text = "-" + text
+	}
+	return text, nil
+}
+
+// incrementDigits прибавляет единицу последнего разряда с переносом
+func incrementDigits(digits []byte, point, base int) ([]byte, int) {
+	for i := len(digits) - 1; i >= 0; i-- {
+		digit := strings.IndexByte(baseDigits, digits[i]) + 1
+		if digit < base {
+			digits[i] = baseDigits[digit]
+			return digits, point
+		}
+		digits[i] = '0'
+	}
+	return append([]byte{'1'}, digits...), point + 1
+}
+
+// Rationalize находит ближайшую к x дробь со знаменателем не больше
+// maxDenominator с помощью цепной дроби: 0.333333 при maxDenominator 100 - 1/3
+func Rationalize(x float64, maxDenominator int64) (*big.Rat, error) {
+	if maxDenominator < 1 || math.IsNaN(x) || math.IsInf(x, 0) {
+		return nil, ErrInvalidArgument
+	}
+
+	target := new(big.Rat).SetFloat64(x)
+	limit := big.NewInt(maxDenominator)
+	if target.Denom().Cmp(limit) <= 0 {
+		return target, nil
+	}
+
+	// Подходящие дроби p0/q0 и p1/q1; n/d - остаток цепной дроби
+	p0, q0, p1, q1 := big.NewInt(0), big.NewInt(1), big.NewInt(1), big.NewInt(0)
+	n, d := new(big.Int).Set(target.Num()), new(big.Int).Set(target.Denom())
+	for {
+		a, remainder := new(big.Int).DivMod(n, d, new(big.Int))
+		q2 := new(big.Int).Add(q0, new(big.Int).Mul(a, q1))
+		if q2.Cmp(limit) > 0 {
+			break
+		}
+		p0, q0, p1, q1 = p1, q1, new(big.Int).Add(p0, new(big.Int).Mul(a, p1)), q2
+		n, d = d, remainder
+	}
+
+	// Лучшее приближение - последняя подходящая дробь или промежуточная дробь
+	k := new(big.Int).Quo(new(big.Int).Sub(limit, q0), q1)
+	semiconvergent := new(big.Rat).SetFrac(
+		new(big.Int).Add(p0, new(big.Int).Mul(k, p1)),
+		new(big.Int).Add(q0, new(big.Int).Mul(k, q1)),
+	)
+	convergent := new(big.Rat).SetFrac(p1, q1)
+
+	distance := func(r *big.Rat) *big.Rat {
+		difference := new(big.Rat).Sub(r, target)
+		return difference.Abs(difference)
+	}
+	if distance(convergent).Cmp(distance(semiconvergent)) <= 0 {
+		return convergent, nil
+	}
+	return semiconvergent, nil
+}
@@ -0,0 +1,83 @@
+package calculation_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/flexer2006/y.lms_sprint1_Calc/pkg/calculation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatBase(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       float64
+		base        int
+		expected    string
+		expectedErr error
+	}{
+		{"binary", 10, 2, "1010", nil},
+		{"hexadecimal", 255, 16, "ff", nil},
+		{"base 36", 35, 36, "z", nil},
+		{"zero", 0, 8, "0", nil},
+		{"negative", -255, 16, "-ff", nil},
+		{"binary fraction", 0.625, 2, "0.101", nil},
+		{"hexadecimal fraction", 255.5, 16, "ff.8", nil},
+		{"repeating fraction rounded", 0.1, 3, "0.0022002200220022002200220022002201", nil},
+		{"third in base 3", 1.0 / 3, 3, "0.1", nil},
+		{"carry into integer part", 0.9999999999999999, 10, "1", nil},
+		{"large integer exact", 1e20, 16, "56bc75e2d63100000", nil},
+		{"base 10", 0.1, 10, "0.1", nil},
+		{"power of two base is exact", 0.1, 16, "0.1999999999999a", nil},
+		{"base too small", 10, 1, "", calculation.ErrInvalidBase},
+		{"base too large", 10, 37, "", calculation.ErrInvalidBase},
+		{"infinity", math.Inf(1), 2, "", calculation.ErrInvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := calculation.FormatBase(tt.value, tt.base)
+			if tt.expectedErr != nil {
+				assert.Equal(t, tt.expectedErr, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestRationalize(t *testing.T) {
+	tests := []struct {
+		name           string
+		value          float64
+		maxDenominator int64
+		expected       string
+		expectedErr    error
+	}{
+		{"third", 0.333333, 100, "1/3", nil},
+		{"exact within limit", 0.333333, 1000000, "333333/1000000", nil},
+		{"pi", math.Pi, 1000, "355/113", nil},
+		{"pi small denominator", math.Pi, 10, "22/7", nil},
+		{"semiconvergent", math.Pi, 100, "311/99", nil},
+		{"negative", -0.75, 10, "-3/4", nil},
+		{"integer", 5, 1, "5/1", nil},
+		{"rounds to integer", 2.6, 1, "3/1", nil},
+		{"tie prefers convergent", 0.5, 1, "0/1", nil},
+		{"invalid denominator", 0.5, 0, "", calculation.ErrInvalidArgument},
+		{"nan", math.NaN(), 10, "", calculation.ErrInvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := calculation.Rationalize(tt.value, tt.maxDenominator)
+			if tt.expectedErr != nil {
+				assert.Equal(t, tt.expectedErr, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result.String())
+		})
+	}
+}
@@ -2,200 +2,164 @@
 package calculation
 
 import (
-	"strconv"
+	"math"
+	"strings"
 	"unicode"
 )
 
 type operator struct {
-	precedence int                                 // Приоритет операции (1 для сложения и вычитания, 2 для умножения и деления)
-	operation  func(a, b float64) (float64, error) // Операция
+	precedence       int                                 // Приоритет операции (1 для сложения и вычитания, 2 для умножения и деления, 3 для степени, 4 для погрешности)
+	rightAssociative bool                                // Правоассоциативная операция (2^3^2 = 2^(3^2))
+	operation        func(a, b float64) (float64, error) // Операция
 }
 
+// Внутренние обозначения поэлементных операций .*, ./ и .^
+const (
+	opElementwiseMul = '⊙'
+	opElementwiseDiv = '⊘'
+	opElementwisePow = '⊛'
+)
+
+// Внутренние обозначения наценки и скидки a + b% и a - b%: процент берется от a
+const (
+	opPercentAdd = '⊕'
+	opPercentSub = '⊖'
+)
+
+// percentBase сложение или вычитание, на котором основана операция с процентом
+var percentBase = map[rune]rune{opPercentAdd: '+', opPercentSub: '-'}
+
 // operators определяет поддерживаемые математические операции калькулятора.
 var operators = map[rune]operator{
-	'+': {1, func(a, b float64) (float64, error) { return a + b, nil }},
-	'-': {1, func(a, b float64) (float64, error) { return a - b, nil }},
-	'*': {2, func(a, b float64) (float64, error) { return a * b, nil }},
-	'/': {2, func(a, b float64) (float64, error) {
-		if b == 0 {
-			return 0, ErrDivisionByZero
-		}
-		return a / b, nil
-	}},
+	'+': {precedence: 1, operation: func(a, b float64) (float64, error) { return a + b, nil }},
+	'-': {precedence: 1, operation: func(a, b float64) (float64, error) { return a - b, nil }},
+	'*': {precedence: 2, operation: func(a, b float64) (float64, error) { return a * b, nil }},
+	'/': {precedence: 2, operation: divide},
+	'^': {precedence: 3, rightAssociative: true, operation: func(a, b float64) (float64, error) { return math.Pow(a, b), nil }},
+	// Поэлементные операции для матриц; над числами совпадают с обычными
+	opElementwiseMul: {precedence: 2, operation: func(a, b float64) (float64, error) { return a * b, nil }},
+	opElementwiseDiv: {precedence: 2, operation: divide},
+	opElementwisePow: {precedence: 3, rightAssociative: true, operation: func(a, b float64) (float64, error) { return math.Pow(a, b), nil }},
+	// a ± a*b/100 без вычисления a дважды: 200 + 15% = 200 + 30
+	opPercentAdd: {precedence: 1, operation: func(a, b float64) (float64, error) { return a + a*b/100, nil }},
+	opPercentSub: {precedence: 1, operation: func(a, b float64) (float64, error) { return a - a*b/100, nil }},
+	// Погрешность имеет смысл только в режиме CalcUncertain; разбирается лишь при включенном режиме
+	'±': {precedence: 4, operation: func(a, b float64) (float64, error) { return 0, ErrInvalidOperator }},
+}
+
+// divide делит числа с проверкой деления на ноль
+func divide(a, b float64) (float64, error) {
+	if b == 0 {
+		return 0, ErrDivisionByZero
+	}
+	return a / b, nil
+}
+
+// Options настройки разбора выражений, которые включаются явно
+type Options struct {
+	Percent                bool // % - процент как на настольном калькуляторе: 200 + 15% = 230, 50% * 80 = 40
+	ImplicitMultiplication bool // 2x, 3pi, 2(3+4), (1+2)(3+4); связывает сильнее * и /, но слабее ^
+	// Locale язык ввода чисел, например "ru-RU": в языках с десятичной запятой
+	// запятая между цифрами отделяет дробную часть: 3,5
+	Locale string
+	// SpecialValues политика для переполнения, потери значимости и NaN
+	// в Calc и Evaluate; по умолчанию - ошибка
+	SpecialValues SpecialValuesPolicy
+}
+
+// decimalCommaLanguages языки, в которых дробная часть отделяется запятой
+var decimalCommaLanguages = map[string]bool{
+	"ru": true, "uk": true, "be": true, "kk": true, "uz": true,
+	"de": true, "fr": true, "es": true, "it": true, "pt": true,
+	"nl": true, "pl": true, "cs": true, "sk": true, "sv": true,
+	"fi": true, "da": true, "nb": true, "tr": true, "id": true,
+}
+
+// usesDecimalComma проверяет, отделяет ли язык локали дробную часть запятой
+func usesDecimalComma(locale string) bool {
+	language, _, _ := strings.Cut(strings.ReplaceAll(locale, "_", "-"), "-")
+	return decimalCommaLanguages[strings.ToLower(language)]
 }
 
 // Calculator хранит состояние вычислений
 type Calculator struct {
-	numbers    []float64 // Список чисел
-	operations []rune    // Список операций
+	variables map[string]float64 // Значения переменных
+	options   Options            // Настройки разбора выражений
+	// Счетчик вычислений подвыражений специальной формы, общий для вложенных форм
+	evaluations *int
+	// Сумма оценок погрешности интегралов последнего вычисленного выражения
+	integrationError float64
 }
 
 // NewCalculator создает новый экземпляр калькулятора
 func NewCalculator() *Calculator {
 	return &Calculator{
-		numbers:    make([]float64, 0, 8),
-		operations: make([]rune, 0, 8),
+		variables: make(map[string]float64),
 	}
 }
 
-// applyOperation применяет операцию к двум последними числами в стеке
-func (c *Calculator) applyOperation() error {
-	if len(c.numbers) < 2 || len(c.operations) == 0 {
-		return ErrInvalidExpression
-	}
-
-	b, a := c.numbers[len(c.numbers)-1], c.numbers[len(c.numbers)-2]
-	c.numbers = c.numbers[:len(c.numbers)-2]
-
-	op := c.operations[len(c.operations)-1]
-	c.operations = c.operations[:len(c.operations)-1]
-
-	operator, exists := operators[op]
-	if !exists {
-		return ErrInvalidOperator
-	}
-
-	result, err := operator.operation(a, b)
-	if err != nil {
-		return err
-	}
-
-	c.numbers = append(c.numbers, result)
-	return nil
+// SetVariable задает значение переменной, которое будет подставлено в выражение
+func (c *Calculator) SetVariable(name string, value float64) {
+	c.variables[name] = value
 }
 
-// parseNumber читает число из строки и добавляет его в стек чисел
-func (c *Calculator) parseNumber(expression string, startIndex int) (int, error) {
-	endIndex := startIndex
-	for endIndex < len(expression) && (unicode.IsDigit(rune(expression[endIndex])) || rune(expression[endIndex]) == '.' || rune(expression[endIndex]) == 'e' || rune(expression[endIndex]) == '+' || rune(expression[endIndex]) == '-') {
-		endIndex++
-	}
+// IntegrationError возвращает оценку абсолютной погрешности интегралов
+// последнего вычисленного выражения - сумму оценок всех integrate в нем,
+// кроме вложенных в другие специальные формы
+func (c *Calculator) IntegrationError() float64 {
+	return c.integrationError
+}
 
-	number, err := strconv.ParseFloat(expression[startIndex:endIndex], 64)
-	if err != nil {
-		return endIndex, ErrInvalidExpression
-	}
+// SetOptions задает настройки разбора выражений
+func (c *Calculator) SetOptions(options Options) {
+	c.options = options
+}
 
-	c.numbers = append(c.numbers, number)
-	return endIndex - 1, nil
+// parse разбирает выражение с настройками режима вычисления и калькулятора
+func (c *Calculator) parse(expression string, options parseOptions) (node, error) {
+	options.percent = c.options.Percent
+	options.implicit = c.options.ImplicitMultiplication
+	options.variables = c.variables
+	options.decimalComma = usesDecimalComma(c.options.Locale)
+	return parse(expression, options)
 }
 
 // Calc вычисляет значение математического выражения
 func Calc(expression string) (float64, error) {
-	calc := NewCalculator()
-	expectNumber := true
-
-	for i := 0; i < len(expression); i++ {
-		currentChar := rune(expression[i])
-
-		switch {
-		case unicode.IsDigit(currentChar) || currentChar == '.':
-			var err error
-			i, err = calc.parseNumber(expression, i)
-			if err != nil {
-				return 0, err
-			}
-			expectNumber = false
-
-		case currentChar == '(':
-			calc.operations = append(calc.operations, currentChar)
-			expectNumber = true
-
-		case currentChar == ')':
-			if expectNumber {
-				return 0, ErrInvalidExpression
-			}
-			err := calc.handleClosingParenthesis()
-			if err != nil {
-				return 0, err
-			}
-			expectNumber = false
-
-		case currentChar == '-' && expectNumber:
-			if i+1 >= len(expression) {
-				return 0, ErrInvalidExpression
-			}
-			// Handle unary minus
-			calc.numbers = append(calc.numbers, 0)
-			calc.operations = append(calc.operations, '-')
-			expectNumber = true
-
-		case isOperator(currentChar):
-			if expectNumber {
-				return 0, ErrInvalidExpression
-			}
-			err := calc.handleOperator(currentChar)
-			if err != nil {
-				return 0, err
-			}
-			expectNumber = true
-
-		case !unicode.IsSpace(currentChar):
-			return 0, ErrInvalidCharacter
-
-		case unicode.IsSpace(currentChar):
-			continue
-		}
-	}
-
-	if expectNumber {
-		return 0, ErrInvalidExpression
-	}
-
-	for _, op := range calc.operations {
-		if op == '(' {
-			return 0, ErrMismatchedParens
-		}
-	}
-
-	return calc.calculateFinal()
+	return CalcWithVariables(expression, nil)
 }
 
-// handleClosingParenthesis обрабатывает закрывающую скобку
-func (c *Calculator) handleClosingParenthesis() error {
-	for len(c.operations) > 0 && c.operations[len(c.operations)-1] != '(' {
-		if err := c.applyOperation(); err != nil {
-			return err
-		}
-	}
-
-	if len(c.operations) == 0 {
-		return ErrMismatchedParens
+// CalcWithVariables вычисляет значение выражения, подставляя значения переменных
+func CalcWithVariables(expression string, variables map[string]float64) (float64, error) {
+	calc := NewCalculator()
+	for name, value := range variables {
+		calc.SetVariable(name, value)
 	}
+	return calc.Calc(expression)
+}
 
-	c.operations = c.operations[:len(c.operations)-1]
-	return nil
-}
-
-// handleOperator обрабатывает оператор
-func (c *Calculator) handleOperator(op rune) error {
-	currentOp := operators[op]
-	for len(c.operations) > 0 {
-		lastOp := c.operations[len(c.operations)-1]
-		if lastOp == '(' || operators[lastOp].precedence < currentOp.precedence {
-			break
-		}
-		if err := c.applyOperation(); err != nil {
-			return err
-		}
+// Calc вычисляет значение выражения с учетом заданных переменных
+func (c *Calculator) Calc(expression string) (float64, error) {
+	root, err := c.parse(expression, parseOptions{durations: true})
+	if err != nil {
+		return 0, err
 	}
-	c.operations = append(c.operations, op)
-	return nil
+	c.integrationError = 0
+	return c.eval(root)
 }
 
-// calculateFinal вычисляет окончательный результат
-func (c *Calculator) calculateFinal() (float64, error) {
-	for len(c.operations) > 0 {
-		if err := c.applyOperation(); err != nil {
-			return 0, err
-		}
+// eval вычисляет узел синтаксического дерева, значением которого должно быть число
+func (c *Calculator) eval(n node) (float64, error) {
+	value, err := c.evalValue(n)
+	if err != nil {
+		return 0, err
 	}
 
-	if len(c.numbers) != 1 {
-		return 0, ErrInvalidExpression
+	number, ok := value.(Number)
+	if !ok {
+		return 0, ErrNotScalar
 	}
-
-	return c.numbers[0], nil
+	return float64(number), nil
 }
 
 // isOperator проверяет, является ли символ оператором
@@ -203,3 +167,8 @@ func isOperator(ch rune) bool {
 	_, exists := operators[ch]
 	return exists
 }
+
+// isIdentifierChar проверяет, может ли символ входить в имя переменной
+func isIdentifierChar(ch rune) bool {
+	return unicode.IsLetter(ch) || unicode.IsDigit(ch) || ch == '_'
+}
@@ -1,6 +1,10 @@
 package calculation
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
 
 var (
 	// Неправильный формат или не вычисляется
@@ -13,4 +17,80 @@ var (
 	ErrMismatchedParens = errors.New("mismatched parentheses")
 	// Неправильный символ
 	ErrInvalidCharacter = errors.New("invalid character")
+	// Неизвестная переменная
+	ErrUnknownVariable = errors.New("unknown variable")
+	// Неизвестная функция
+	ErrUnknownFunction = errors.New("unknown function")
+	// Неправильное количество аргументов функции
+	ErrArgumentCount = errors.New("wrong number of arguments")
+	// Аргумент вне области определения функции
+	ErrInvalidArgument = errors.New("invalid argument")
+	// Неизвестная единица измерения
+	ErrUnknownUnit = errors.New("unknown unit")
+	// Несовместимые размерности (1 m + 1 s)
+	ErrDimensionMismatch = errors.New("dimension mismatch")
+	// Пустой интервал (деление на интервал [0, 0])
+	ErrEmptyInterval = errors.New("empty interval")
+	// Результат - не число (например, матрица там, где ожидается число)
+	ErrNotScalar = errors.New("result is not a number")
+	// Вырожденная матрица не имеет обратной
+	ErrSingularMatrix = errors.New("singular matrix")
+	// Уравнение записано без знака равенства или с несколькими
+	ErrInvalidEquation = errors.New("invalid equation")
+	// Отрезок поиска корня задан неправильно
+	ErrInvalidBracket = errors.New("invalid bracket")
+	// Корень уравнения не найден
+	ErrNoRoot = errors.New("no root found")
+	// Уравнение выполняется при любом значении переменной (x = x)
+	ErrIdentity = errors.New("equation holds for every value")
+	// Интеграл или ряд не вычислен за допустимое число вычислений подвыражения
+	ErrNotConverged = errors.New("did not converge")
+	// Операция не определена для типов операндов (сумма двух дат, длительность плюс число)
+	ErrTypeMismatch = errors.New("incompatible operand types")
+	// Строка не является датой в формате ISO 8601
+	ErrInvalidDate = errors.New("invalid date")
+	// В целочисленном режиме результат или операнд не целый (7/2, 2^-1, pi)
+	ErrNotInteger = errors.New("result is not an integer")
+	// Целочисленный результат слишком велик
+	ErrResultTooLarge = errors.New("result is too large")
+	// Неправильная запись числа: 1.2.3, 1e, 1e+, 1_ или число вне диапазона float64
+	ErrInvalidNumber = errors.New("invalid number")
+	// Запятая между цифрами в аргументах функции при десятичной запятой: nCr(5,2)
+	ErrAmbiguousComma = errors.New("ambiguous comma between digits")
+	// Результат по модулю больше наибольшего float64 (1e308 * 10)
+	ErrOverflow = errors.New("result overflows")
+	// Ненулевой результат по модулю меньше наименьшего нормального float64 (1e-200 * 1e-200)
+	ErrUnderflow = errors.New("result underflows")
+	// Результат не определен (inf - inf, (-8)^(1/3))
+	ErrNaN = errors.New("result is not a number (NaN)")
+	// Неизвестная политика обработки особых значений
+	ErrUnknownPolicy = errors.New("unknown special values policy")
+	// Неправильные настройки форматирования: неизвестная запись или округление, Decimals вместе с Significant
+	ErrInvalidFormat = errors.New("invalid format options")
+	// Основание системы счисления вне диапазона от 2 до 36
+	ErrInvalidBase = errors.New("base must be between 2 and 36")
+	// Выражение нельзя разбить на операции над числами (матрицы, даты, специальные формы)
+	ErrNotDistributable = errors.New("expression cannot be split into tasks")
+	// Операции с таким номером нет в плане, она не выдана или уже вычислена
+	ErrUnknownTask = errors.New("unknown task")
 )
+
+// SyntaxError ошибка разбора с позицией в выражении; сравнивается с причиной
+// через errors.Is: errors.Is(err, ErrInvalidNumber)
+type SyntaxError struct {
+	Position int   // Номер символа от начала выражения, начиная с нуля
+	Err      error // Причина ошибки
+}
+
+// newSyntaxError создает ошибку разбора; смещение в байтах переводится в номер символа
+func newSyntaxError(expression string, offset int, err error) *SyntaxError {
+	return &SyntaxError{Position: utf8.RuneCountInString(expression[:offset]), Err: err}
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%v at position %d", e.Err, e.Position)
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
@@ -35,7 +35,7 @@ func TestCalc(t *testing.T) {
 		{"mismatched parentheses", "(2 + 2", 0, true, calculation.ErrMismatchedParens},
 		{"empty expression", "", 0, true, calculation.ErrInvalidExpression},
 		{"double operators", "2 ++ 2", 0, true, calculation.ErrInvalidExpression},
-		{"invalid number format", "2.2.2 + 1", 0, true, calculation.ErrInvalidExpression},
+		{"invalid number format", "2.2.2 + 1", 0, true, &calculation.SyntaxError{Position: 3, Err: calculation.ErrInvalidNumber}},
 		{"empty parentheses", "()", 0, true, calculation.ErrInvalidExpression},
 		{"missing opening parenthesis", "1 + 2)", 0, true, calculation.ErrMismatchedParens},
 		{"missing closing parenthesis", "(1 + 2", 0, true, calculation.ErrMismatchedParens},
@@ -126,7 +126,7 @@ func TestCalculator_EdgeCases(t *testing.T) {
 			name:     "multiple decimal points",
 			input:    "1.2.3 + 4",
 			hasError: true,
-			err:      calculation.ErrInvalidExpression,
+			err:      &calculation.SyntaxError{Position: 3, Err: calculation.ErrInvalidNumber},
 		},
 		{
 			name:     "only parentheses",
@@ -290,3 +290,70 @@ func TestCalculator_SpecificErrors(t *testing.T) {
 		})
 	}
 }
+
+func TestCalcWithVariables(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		variables map[string]float64
+		expected  float64
+		err       error
+	}{
+		{"single variable", "x * 2", map[string]float64{"x": 3}, 6, nil},
+		{"several variables", "price * qty - cost", map[string]float64{"price": 10, "qty": 5, "cost": 20}, 30, nil},
+		{"power", "x ^ 2", map[string]float64{"x": 3}, 9, nil},
+		{"power is right associative", "2 ^ 3 ^ 2", nil, 512, nil},
+		{"power before unary minus", "-2 ^ 2", nil, -4, nil},
+		{"power precedence", "2 * 3 ^ 2", nil, 18, nil},
+		{"unknown variable", "y + 1", map[string]float64{"x": 1}, 0, calculation.ErrUnknownVariable},
+		{"variable after number", "2 x", map[string]float64{"x": 1}, 0, calculation.ErrInvalidExpression},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := calculation.CalcWithVariables(tt.input, tt.variables)
+
+			if tt.err != nil {
+				assert.Equal(t, tt.err, err)
+			} else {
+				assert.NoError(t, err)
+				assert.InDelta(t, tt.expected, result, 0.0001)
+			}
+		})
+	}
+}
+
+func TestCalc_Functions(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected float64
+		err      error
+	}{
+		{"sqrt", "sqrt(16) + 1", 5, nil},
+		{"nested calls", "max(1, min(5, 3), 2)", 3, nil},
+		{"constant", "2 * pi", 6.283185307, nil},
+		{"log with base", "log(8, 2)", 3, nil},
+		{"unary minus before call", "-abs(-2)", -2, nil},
+		{"scientific notation without spaces", "2e3-1", 1999, nil},
+		{"no spaces", "2+2*2", 6, nil},
+		{"unknown function", "foo(1)", 0, calculation.ErrUnknownFunction},
+		{"too many arguments", "sqrt(1, 2)", 0, calculation.ErrArgumentCount},
+		{"domain error", "sqrt(-1)", 0, calculation.ErrInvalidArgument},
+		{"missing closing parenthesis", "sqrt(4", 0, calculation.ErrMismatchedParens},
+		{"trailing comma", "max(1,)", 0, calculation.ErrInvalidExpression},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := calculation.Calc(tt.input)
+
+			if tt.err != nil {
+				assert.Equal(t, tt.err, err)
+			} else {
+				assert.NoError(t, err)
+				assert.InDelta(t, tt.expected, result, 0.0001)
+			}
+		})
+	}
+}
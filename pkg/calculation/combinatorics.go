@@ -0,0 +1,177 @@
+package calculation
+
+import (
+	"math"
+	"math/big"
+)
+
+// maxExactInteger наибольшее целое, все меньшие которого представимы в float64
+const maxExactInteger = 1 << 53
+
+// maxFactorial наибольшее n, для которого n! конечно в float64
+const maxFactorial = 170
+
+// integerArgument проверяет, что аргумент - целое число, представимое точно
+func integerArgument(x float64) (int64, error) {
+	if x != math.Trunc(x) || math.Abs(x) > maxExactInteger {
+		return 0, ErrInvalidArgument
+	}
+	return int64(x), nil
+}
+
+// factorial вычисляет x!; для нецелых x - через гамма-функцию: x! = Γ(x+1)
+func factorial(args []float64) (float64, error) {
+	x := args[0]
+	if x < 0 && x == math.Trunc(x) {
+		return 0, ErrInvalidArgument
+	}
+	if x != math.Trunc(x) {
+		return math.Gamma(x + 1), nil
+	}
+	if x > maxFactorial {
+		return math.Inf(1), nil
+	}
+
+	result := 1.0
+	for i := 2.0; i <= x; i++ {
+		result *= i
+	}
+	return result, nil
+}
+
+// gamma вычисляет гамма-функцию; в нуле и отрицательных целых она не определена
+func gamma(args []float64) (float64, error) {
+	x := args[0]
+	if x <= 0 && x == math.Trunc(x) {
+		return 0, ErrInvalidArgument
+	}
+	return math.Gamma(x), nil
+}
+
+// combinationArguments проверяет аргументы nCr и nPr: целые 0 <= k, 0 <= n
+func combinationArguments(args []float64) (int64, int64, error) {
+	n, err := integerArgument(args[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	k, err := integerArgument(args[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	if n < 0 || k < 0 {
+		return 0, 0, ErrInvalidArgument
+	}
+	return n, k, nil
+}
+
+// combinations вычисляет число сочетаний nCr(n, k)
+func combinations(args []float64) (float64, error) {
+	n, k, err := combinationArguments(args)
+	if err != nil {
+		return 0, err
+	}
+	if k > n {
+		return 0, nil
+	}
+	if k > n-k {
+		k = n - k
+	}
+
+	// Произведение дробей (n-k+i)/i после каждого шага остается целым
+	result := 1.0
+	for i := int64(1); i <= k && !math.IsInf(result, 1); i++ {
+		result = result * float64(n-k+i) / float64(i)
+	}
+	return math.Round(result), nil
+}
+
+// permutations вычисляет число размещений nPr(n, k)
+func permutations(args []float64) (float64, error) {
+	n, k, err := combinationArguments(args)
+	if err != nil {
+		return 0, err
+	}
+	if k > n {
+		return 0, nil
+	}
+
+	result := 1.0
+	for i := int64(0); i < k && !math.IsInf(result, 1); i++ {
+		result *= float64(n - i)
+	}
+	return result, nil
+}
+
+// integerFold сворачивает целые аргументы функцией над big.Int
+func integerFold(f func(a, b *big.Int) *big.Int) func(args []float64) (float64, error) {
+	return func(args []float64) (float64, error) {
+		result := new(big.Int)
+		for i, arg := range args {
+			value, err := integerArgument(arg)
+			if err != nil {
+				return 0, err
+			}
+			if i == 0 {
+				result.SetInt64(value)
+				continue
+			}
+			result = f(result, big.NewInt(value))
+		}
+		value, _ := new(big.Float).SetInt(result).Float64()
+		return value, nil
+	}
+}
+
+// gcd наибольший общий делитель; всегда неотрицателен
+func gcd(a, b *big.Int) *big.Int {
+	return new(big.Int).GCD(nil, nil, new(big.Int).Abs(a), new(big.Int).Abs(b))
+}
+
+// lcm наименьшее общее кратное; lcm с нулем равно нулю
+func lcm(a, b *big.Int) *big.Int {
+	if a.Sign() == 0 || b.Sign() == 0 {
+		return new(big.Int)
+	}
+	product := new(big.Int).Mul(a, b)
+	return product.Abs(product).Quo(product, gcd(a, b))
+}
+
+// maxModularBits ограничивает размер аргументов isprime и mod_pow: время их
+// вычисления растет как куб длины числа, и 10^100000 занимало бы вычислитель
+// на минуты
+const maxModularBits = 4096
+
+// checkModularSize проверяет, что аргументы не длиннее maxModularBits
+func checkModularSize(args ...*big.Int) error {
+	for _, arg := range args {
+		if arg.BitLen() > maxModularBits {
+			return ErrResultTooLarge
+		}
+	}
+	return nil
+}
+
+// isPrime проверяет простоту числа; результат 1 или 0.
+// ProbablyPrime точен для всех чисел меньше 2^64.
+func isPrime(x *big.Int) (bool, error) {
+	if err := checkModularSize(x); err != nil {
+		return false, err
+	}
+	return x.Sign() > 0 && x.ProbablyPrime(20), nil
+}
+
+// modPow вычисляет b^e mod m для целых e >= 0 и m > 0
+func modPow(b, e, m *big.Int) (*big.Int, error) {
+	if err := checkModularSize(b, e, m); err != nil {
+		return nil, err
+	}
+	if e.Sign() < 0 || m.Sign() <= 0 {
+		return nil, ErrInvalidArgument
+	}
+	result := new(big.Int).Exp(b, e, m)
+	// Exp возвращает остаток со знаком основания; приводим к [0, m)
+	if result.Sign() < 0 {
+		result.Add(result, m)
+	}
+	return result, nil
+}
@@ -0,0 +1,103 @@
+package calculation_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/flexer2006/y.lms_sprint1_Calc/pkg/calculation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalc_Combinatorics(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    float64
+		expectedErr error
+	}{
+		{"factorial", "5!", 120, nil},
+		{"zero factorial", "0!", 1, nil},
+		{"factorial binds tighter than power", "2^3!", 64, nil},
+		{"factorial binds tighter than minus", "-3!", -6, nil},
+		{"double factorial application", "3!!", 720, nil},
+		{"factorial of expression", "(1 + 2)! * 2", 12, nil},
+		{"non-integer factorial", "0.5!", math.Sqrt(math.Pi) / 2, nil},
+		{"factorial overflow", "171!", 0, calculation.ErrOverflow},
+		{"negative integer factorial", "(-1)!", 0, calculation.ErrInvalidArgument},
+		{"gamma", "gamma(5)", 24, nil},
+		{"gamma pole", "gamma(0)", 0, calculation.ErrInvalidArgument},
+		{"combinations", "nCr(10, 3)", 120, nil},
+		{"combinations symmetric", "nCr(52, 47)", 2598960, nil},
+		{"combinations k greater than n", "nCr(3, 5)", 0, nil},
+		{"permutations", "nPr(10, 3)", 720, nil},
+		{"non-integer combinations", "nCr(5.5, 2)", 0, calculation.ErrInvalidArgument},
+		{"gcd", "gcd(48, 18, 30)", 6, nil},
+		{"gcd of negative", "gcd(-12, 8)", 4, nil},
+		{"lcm", "lcm(4, 6, 10)", 60, nil},
+		{"isprime", "isprime(97)", 1, nil},
+		{"isprime composite", "isprime(91)", 0, nil},
+		{"isprime one", "isprime(1)", 0, nil},
+		{"mod_pow", "mod_pow(4, 13, 497)", 445, nil},
+		{"mod_pow large exponent", "mod_pow(2, 1e15, 1000000007)", 264444359, nil},
+		{"mod_pow negative base", "mod_pow(-2, 3, 5)", 2, nil},
+		{"mod_pow zero modulus", "mod_pow(2, 3, 0)", 0, calculation.ErrInvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := calculation.Calc(tt.input)
+			if tt.expectedErr != nil {
+				assert.Equal(t, tt.expectedErr, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.InDelta(t, tt.expected, result, 1e-9)
+		})
+	}
+}
+
+func TestCalcInteger(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    string
+		expectedErr error
+	}{
+		{"factorial", "30!", "265252859812191058636308480000000", nil},
+		{"power", "2^100", "1267650600228229401496703205376", nil},
+		{"combinations", "nCr(100, 50)", "100891344545564193334812497256", nil},
+		{"permutations", "nPr(30, 20)", "73096577329197271449600000", nil},
+		{"exact division", "30! / 28!", "870", nil},
+		{"scientific literal", "1e3 + 1", "1001", nil},
+		{"gcd", "gcd(2^64, 6^40)", "1099511627776", nil},
+		{"lcm", "lcm(2^40, 3^20)", "3833759992447475122176", nil},
+		{"isprime", "isprime(2^61 - 1)", "1", nil},
+		{"mod_pow", "mod_pow(3, 10^20, 10^9 + 7)", "139421235", nil},
+		{"isprime at size limit", "isprime(2^4096 - 1)", "0", nil},
+		{"too large mod_pow exponent", "mod_pow(3, 10^100000, 10^9 + 7)", "", calculation.ErrResultTooLarge},
+		{"too large mod_pow modulus", "mod_pow(3, 10^10000, 10^10000 + 1)", "", calculation.ErrResultTooLarge},
+		{"too large isprime", "isprime(10^100000 + 1)", "", calculation.ErrResultTooLarge},
+		{"negative", "-(5!) + 20", "-100", nil},
+		{"inexact division", "7 / 2", "", calculation.ErrNotInteger},
+		{"fractional literal", "1.5 * 2", "", calculation.ErrNotInteger},
+		{"negative exponent", "2^-1", "", calculation.ErrNotInteger},
+		{"constant", "pi", "", calculation.ErrNotInteger},
+		{"too large power", "10^10^10", "", calculation.ErrResultTooLarge},
+		{"too large factorial", "100000!", "", calculation.ErrResultTooLarge},
+		{"division by zero", "1 / 0", "", calculation.ErrDivisionByZero},
+		{"unsupported function", "sqrt(4)", "", calculation.ErrUnknownFunction},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := calculation.CalcInteger(tt.input)
+			if tt.expectedErr != nil {
+				assert.Equal(t, tt.expectedErr, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result.String())
+		})
+	}
+}
@@ -0,0 +1,309 @@
+package calculation
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	_ "time/tzdata" // База часовых поясов на случай, если в системе ее нет
+)
+
+// Time момент времени с часовым поясом
+type Time time.Time
+
+func (Time) isValue() {}
+
+// String возвращает момент времени в формате ISO 8601
+func (t Time) String() string {
+	return time.Time(t).Format(time.RFC3339Nano)
+}
+
+// Duration длительность; сутки в выражениях равны 24 часам
+type Duration time.Duration
+
+func (Duration) isValue() {}
+
+// String возвращает длительность в формате ISO 8601: P30DT2H30M
+func (d Duration) String() string {
+	if d == 0 {
+		return "PT0S"
+	}
+
+	var b strings.Builder
+	if d < 0 {
+		b.WriteByte('-')
+		d = -d
+	}
+	b.WriteByte('P')
+
+	day := Duration(24 * time.Hour)
+	if days := d / day; days > 0 {
+		fmt.Fprintf(&b, "%dD", days)
+		d -= days * day
+	}
+	if d == 0 {
+		return b.String()
+	}
+
+	b.WriteByte('T')
+	if hours := d / Duration(time.Hour); hours > 0 {
+		fmt.Fprintf(&b, "%dH", hours)
+		d -= hours * Duration(time.Hour)
+	}
+	if minutes := d / Duration(time.Minute); minutes > 0 {
+		fmt.Fprintf(&b, "%dM", minutes)
+		d -= minutes * Duration(time.Minute)
+	}
+	if d > 0 {
+		b.WriteString(strconv.FormatFloat(time.Duration(d).Seconds(), 'f', -1, 64))
+		b.WriteByte('S')
+	}
+	return b.String()
+}
+
+// localDateLayouts записи даты без смещения; время берется в заданном поясе
+var localDateLayouts = []string{
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// temporalFunction функция над датами и длительностями; получает аргументы
+// невычисленными, так как дата и часовой пояс записываются строками
+type temporalFunction struct {
+	minArgs int                                             // Минимальное количество аргументов
+	maxArgs int                                             // Максимальное количество аргументов
+	call    func(c *Calculator, args []node) (Value, error) // Вычисление функции
+}
+
+// temporalFunctions определяет функции дат. Заполняется в init, так как
+// функции вычисляют аргументы через evalValue.
+var temporalFunctions map[string]temporalFunction
+
+func init() {
+	temporalFunctions = map[string]temporalFunction{
+		// date("2026-10-17"), date("2026-10-17T09:00", "Europe/Moscow")
+		"date": {1, 2, func(c *Calculator, args []node) (Value, error) {
+			text, err := stringArgument(args[0])
+			if err != nil {
+				return nil, err
+			}
+			if len(args) == 1 {
+				return parseDate(text, nil)
+			}
+			location, err := locationArgument(args[1])
+			if err != nil {
+				return nil, err
+			}
+			return parseDate(text, location)
+		}},
+		// now() или now("Asia/Tokyo")
+		"now": {0, 1, func(c *Calculator, args []node) (Value, error) {
+			location := time.UTC
+			if len(args) == 1 {
+				var err error
+				if location, err = locationArgument(args[0]); err != nil {
+					return nil, err
+				}
+			}
+			return Time(time.Now().In(location)), nil
+		}},
+		// tz(t, "America/New_York") - тот же момент в другом часовом поясе
+		"tz": {2, 2, func(c *Calculator, args []node) (Value, error) {
+			t, err := c.evalTime(args[0])
+			if err != nil {
+				return nil, err
+			}
+			location, err := locationArgument(args[1])
+			if err != nil {
+				return nil, err
+			}
+			return Time(time.Time(t).In(location)), nil
+		}},
+		// days_between(a, b) - количество суток от a до b, возможно дробное
+		"days_between": {2, 2, func(c *Calculator, args []node) (Value, error) {
+			a, err := c.evalTime(args[0])
+			if err != nil {
+				return nil, err
+			}
+			b, err := c.evalTime(args[1])
+			if err != nil {
+				return nil, err
+			}
+			return Number(time.Time(b).Sub(time.Time(a)).Hours() / 24), nil
+		}},
+		"days":    durationIn(24 * time.Hour),
+		"hours":   durationIn(time.Hour),
+		"minutes": durationIn(time.Minute),
+		"seconds": durationIn(time.Second),
+	}
+}
+
+// durationIn возвращает функцию, переводящую длительность в число заданных единиц
+func durationIn(unit time.Duration) temporalFunction {
+	return temporalFunction{1, 1, func(c *Calculator, args []node) (Value, error) {
+		value, err := c.evalValue(args[0])
+		if err != nil {
+			return nil, err
+		}
+		d, ok := value.(Duration)
+		if !ok {
+			return nil, ErrTypeMismatch
+		}
+		return Number(float64(d) / float64(unit)), nil
+	}}
+}
+
+// stringArgument проверяет, что аргумент - строковый литерал
+func stringArgument(arg node) (string, error) {
+	text, ok := arg.(stringNode)
+	if !ok {
+		return "", ErrInvalidArgument
+	}
+	return text.value, nil
+}
+
+// locationArgument возвращает часовой пояс по имени из базы IANA
+func locationArgument(arg node) (*time.Location, error) {
+	name, err := stringArgument(arg)
+	if err != nil {
+		return nil, err
+	}
+	location, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, ErrInvalidArgument
+	}
+	return location, nil
+}
+
+// parseDate разбирает дату ISO 8601. Дата со смещением переводится в заданный
+// пояс, дата без смещения считается записанной в нем; без пояса смещение
+// сохраняется, а дата без смещения считается записанной в UTC.
+func parseDate(text string, location *time.Location) (Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, text); err == nil {
+		if location != nil {
+			t = t.In(location)
+		}
+		return Time(t), nil
+	}
+	if location == nil {
+		location = time.UTC
+	}
+	for _, layout := range localDateLayouts {
+		if t, err := time.ParseInLocation(layout, text, location); err == nil {
+			return Time(t), nil
+		}
+	}
+	return Time{}, ErrInvalidDate
+}
+
+// evalTime вычисляет аргумент, который должен быть моментом времени
+func (c *Calculator) evalTime(n node) (Time, error) {
+	value, err := c.evalValue(n)
+	if err != nil {
+		return Time{}, err
+	}
+	t, ok := value.(Time)
+	if !ok {
+		return Time{}, ErrTypeMismatch
+	}
+	return t, nil
+}
+
+// isTemporal проверяет, что значение - момент времени или длительность
+func isTemporal(v Value) bool {
+	switch v.(type) {
+	case Time, Duration:
+		return true
+	}
+	return false
+}
+
+// applyTemporalOperation применяет операцию к датам и длительностям:
+// дата ± длительность, разность дат, сумма длительностей, длительность * число
+func applyTemporalOperation(op rune, a, b Value) (Value, error) {
+	switch a := a.(type) {
+	case Time:
+		switch b := b.(type) {
+		case Duration:
+			switch op {
+			case '+':
+				return Time(time.Time(a).Add(time.Duration(b))), nil
+			case '-':
+				return Time(time.Time(a).Add(-time.Duration(b))), nil
+			}
+		case Time:
+			if op == '-' {
+				return Duration(time.Time(a).Sub(time.Time(b))), nil
+			}
+		}
+
+	case Duration:
+		switch b := b.(type) {
+		case Time:
+			if op == '+' {
+				return Time(time.Time(b).Add(time.Duration(a))), nil
+			}
+		case Duration:
+			switch op {
+			case '+':
+				return addDurations(a, b)
+			case '-':
+				if b == math.MinInt64 {
+					return nil, ErrInvalidArgument
+				}
+				return addDurations(a, -b)
+			case '/':
+				if b == 0 {
+					return nil, ErrDivisionByZero
+				}
+				return Number(float64(a) / float64(b)), nil
+			}
+		case Number:
+			switch op {
+			case '*':
+				return scaleDuration(float64(a) * float64(b))
+			case '/':
+				if b == 0 {
+					return nil, ErrDivisionByZero
+				}
+				return scaleDuration(float64(a) / float64(b))
+			case opPercentAdd, opPercentSub:
+				// Наценка и скидка: 2h + 10% = 2h + 12m
+				share, err := scaleDuration(float64(a) * float64(b) / 100)
+				if err != nil {
+					return nil, err
+				}
+				if op == opPercentSub {
+					return applyTemporalOperation('-', a, share)
+				}
+				return addDurations(a, share.(Duration))
+			}
+		}
+
+	case Number:
+		if b, ok := b.(Duration); ok && op == '*' {
+			return scaleDuration(float64(a) * float64(b))
+		}
+	}
+
+	return nil, ErrTypeMismatch
+}
+
+// addDurations складывает длительности с проверкой переполнения
+func addDurations(a, b Duration) (Value, error) {
+	sum := a + b
+	if (b > 0 && sum < a) || (b < 0 && sum > a) {
+		return nil, ErrInvalidArgument
+	}
+	return sum, nil
+}
+
+// scaleDuration округляет длительность в наносекундах с проверкой переполнения
+func scaleDuration(nanoseconds float64) (Value, error) {
+	if math.IsNaN(nanoseconds) || math.Abs(nanoseconds) >= math.MaxInt64 {
+		return nil, ErrInvalidArgument
+	}
+	return Duration(math.Round(nanoseconds)), nil
+}
@@ -0,0 +1,71 @@
+package calculation_test
+
+import (
+	"testing"
+
+	"github.com/flexer2006/y.lms_sprint1_Calc/pkg/calculation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluate_DateTime(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    string
+		expectedErr error
+	}{
+		{"date plus days", `date("2026-10-17") + 30d`, "2026-11-16T00:00:00Z", nil},
+		{"duration plus date", `2h + date("2026-10-17T09:00:00Z")`, "2026-10-17T11:00:00Z", nil},
+		{"date minus duration", `date("2026-10-17") - 1w`, "2026-10-10T00:00:00Z", nil},
+		{"local date in zone", `date("2026-10-17T09:00", "Europe/Moscow") + 8h`, "2026-10-17T17:00:00+03:00", nil},
+		{"offset kept", `date("2026-10-17T09:00:00+05:00")`, "2026-10-17T09:00:00+05:00", nil},
+		{"convert zone", `tz(date("2026-10-17T12:00:00Z"), "Asia/Tokyo")`, "2026-10-17T21:00:00+09:00", nil},
+		{"compound duration", "2h30m * 3", "PT7H30M", nil},
+		{"fractional duration", "1.5h", "PT1H30M", nil},
+		{"duration sum", "1d + 90s", "P1DT1M30S", nil},
+		{"negative duration", "-(1h + 500ms)", "-PT1H0.5S", nil},
+		{"date difference", `date("2026-10-18T06:00:00Z") - date("2026-10-17")`, "P1DT6H", nil},
+		{"invalid date", `date("17.10.2026")`, "", calculation.ErrInvalidDate},
+		{"unknown zone", `date("2026-10-17", "Mars/Olympus")`, "", calculation.ErrInvalidArgument},
+		{"sum of dates", `date("2026-10-17") + date("2026-10-18")`, "", calculation.ErrTypeMismatch},
+		{"duration plus number", "2h + 3", "", calculation.ErrTypeMismatch},
+		{"date is not a string", "date(2026)", "", calculation.ErrInvalidArgument},
+		{"unterminated string", `date("2026-10-17)`, "", calculation.ErrInvalidExpression},
+		{"suffix is not a duration", "3min", "", calculation.ErrInvalidExpression},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := calculation.Evaluate(tt.input)
+			if tt.expectedErr != nil {
+				assert.Equal(t, tt.expectedErr, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result.(interface{ String() string }).String())
+		})
+	}
+}
+
+func TestCalc_DurationConversions(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected float64
+	}{
+		{"days between", `days_between(date("2026-10-17"), date("2026-11-16T12:00:00Z"))`, 30.5},
+		{"hours", "hours(2h30m * 3)", 7.5},
+		{"minutes", "minutes(1d)", 1440},
+		{"seconds", "seconds(250ms)", 0.25},
+		{"ratio of durations", "1w / 1d", 7},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := calculation.Calc(tt.input)
+			require.NoError(t, err)
+			assert.InDelta(t, tt.expected, result, 1e-9)
+		})
+	}
+}
@@ -0,0 +1,271 @@
+package calculation
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Notation запись числа при форматировании
+type Notation string
+
+const (
+	NotationFixed       Notation = "fixed" // 12345.68
+	NotationScientific  Notation = "sci"   // 1.23e4
+	NotationEngineering Notation = "eng"   // 12.3e3 - порядок кратен трем
+)
+
+// Rounding способ округления при форматировании
+type Rounding string
+
+const (
+	RoundHalfEven Rounding = "half_even" // Половина - к четной цифре (по умолчанию): 2.5 -> 2
+	RoundHalfUp   Rounding = "half_up"   // Половина - от нуля: 2.5 -> 3, -2.5 -> -3
+	RoundHalfDown Rounding = "half_down" // Половина - к нулю: 2.5 -> 2
+	RoundUp       Rounding = "up"        // От нуля: 2.1 -> 3
+	RoundDown     Rounding = "down"      // К нулю (отбрасывание): 2.9 -> 2
+	RoundCeiling  Rounding = "ceiling"   // Вверх: -2.9 -> -2
+	RoundFloor    Rounding = "floor"     // Вниз: -2.1 -> -3
+)
+
+// maxFormatDigits ограничивает количество знаков; 5e-324 записывается 324 знаками после точки
+const maxFormatDigits = 400
+
+// FormatOptions настройки форматирования результата. Без Decimals и Significant
+// число записывается кратчайшим представлением, однозначно задающим float64.
+type FormatOptions struct {
+	Decimals           *int     // Знаков после точки (в sci и eng - в мантиссе)
+	Significant        int      // Значащих цифр; 0 - не задано; несовместимо с Decimals
+	Notation           Notation // Запись числа; по умолчанию fixed
+	Rounding           Rounding // Способ округления; по умолчанию half_even
+	ThousandsSeparator string   // Разделитель групп разрядов целой части в fixed: "1 234 567"
+}
+
+// Validate проверяет настройки форматирования
+func (f FormatOptions) Validate() error {
+	if f.Decimals != nil && (*f.Decimals < 0 || *f.Decimals > maxFormatDigits || f.Significant != 0) {
+		return ErrInvalidFormat
+	}
+	if f.Significant < 0 || f.Significant > maxFormatDigits {
+		return ErrInvalidFormat
+	}
+	switch f.Notation {
+	case "", NotationFixed, NotationScientific, NotationEngineering:
+	default:
+		return ErrInvalidFormat
+	}
+	switch f.Rounding {
+	case "", RoundHalfEven, RoundHalfUp, RoundHalfDown, RoundUp, RoundDown, RoundCeiling, RoundFloor:
+	default:
+		return ErrInvalidFormat
+	}
+	return nil
+}
+
+// Format записывает число строкой с заданным округлением и записью. Округляется
+// десятичная запись числа, поэтому 2.675 с двумя знаками и half_up дает 2.68.
+// Бесконечность и NaN записываются как "Infinity", "-Infinity" и "NaN".
+func Format(x float64, f FormatOptions) (string, error) {
+	if err := f.Validate(); err != nil {
+		return "", err
+	}
+	switch {
+	case math.IsNaN(x):
+		return "NaN", nil
+	case math.IsInf(x, 1):
+		return "Infinity", nil
+	case math.IsInf(x, -1):
+		return "-Infinity", nil
+	}
+
+	d := newDecimal(x)
+	var text string
+	switch f.Notation {
+	case NotationScientific, NotationEngineering:
+		text = d.formatExponent(f)
+	default:
+		text = d.formatFixed(f)
+	}
+
+	// Округленный до нуля результат записывается без знака
+	if d.negative && !d.isZero() {
+		return "-" + text, nil
+	}
+	return text, nil
+}
+
+// decimal десятичная запись числа: 0.digits * 10^point
+type decimal struct {
+	digits   string // Значащие цифры без ведущих нулей; "0" для нуля
+	point    int    // Количество цифр до десятичной точки
+	negative bool   // Знак числа
+}
+
+// newDecimal получает кратчайшую десятичную запись числа
+func newDecimal(x float64) decimal {
+	text := strconv.FormatFloat(math.Abs(x), 'e', -1, 64)
+	mantissa, exponent, _ := strings.Cut(text, "e")
+	power, _ := strconv.Atoi(exponent)
+	return decimal{
+		digits:   strings.Replace(mantissa, ".", "", 1),
+		point:    power + 1,
+		negative: math.Signbit(x),
+	}
+}
+
+// round оставляет keep первых цифр, округляя отброшенные способом mode
+func (d *decimal) round(keep int, mode Rounding) {
+	if keep < 0 {
+		// Все цифры ниже разряда округления: дополняем ведущими нулями
+		d.digits = strings.Repeat("0", -keep) + d.digits
+		d.point -= keep
+		keep = 0
+	}
+	if keep >= len(d.digits) {
+		return
+	}
+
+	kept, dropped := d.digits[:keep], d.digits[keep:]
+	if strings.Trim(dropped, "0") == "" {
+		d.digits = kept
+		return
+	}
+
+	increment := false
+	switch mode {
+	case RoundUp:
+		increment = true
+	case RoundDown:
+	case RoundCeiling:
+		increment = !d.negative
+	case RoundFloor:
+		increment = d.negative
+	default:
+		tie := dropped[0] == '5' && strings.Trim(dropped[1:], "0") == ""
+		switch {
+		case !tie:
+			increment = dropped[0] >= '5'
+		case mode == RoundHalfUp:
+			increment = true
+		case mode == RoundHalfDown:
+		default:
+			increment = kept != "" && (kept[len(kept)-1]-'0')%2 == 1
+		}
+	}
+
+	d.digits = kept
+	if increment {
+		d.increment()
+	}
+}
+
+// increment прибавляет единицу младшего разряда с переносом: 0.999 -> 1.00
+func (d *decimal) increment() {
+	digits := []byte(d.digits)
+	for i := len(digits) - 1; i >= 0; i-- {
+		if digits[i] < '9' {
+			digits[i]++
+			d.digits = string(digits)
+			return
+		}
+		digits[i] = '0'
+	}
+	d.digits = "1" + string(digits)
+	d.point++
+}
+
+// isZero проверяет, что все цифры нулевые
+func (d *decimal) isZero() bool {
+	return strings.Trim(d.digits, "0") == ""
+}
+
+// digitAt возвращает цифру с номером i от начала; за пределами записи - ноль
+func (d decimal) digitAt(i int) byte {
+	if i < 0 || i >= len(d.digits) {
+		return '0'
+	}
+	return d.digits[i]
+}
+
+// digitRange возвращает цифры с номерами [from, to)
+func (d decimal) digitRange(from, to int) string {
+	var b strings.Builder
+	for i := from; i < to; i++ {
+		b.WriteByte(d.digitAt(i))
+	}
+	return b.String()
+}
+
+// formatFixed записывает число без порядка: 12345.68
+func (d *decimal) formatFixed(f FormatOptions) string {
+	decimals := max(len(d.digits)-d.point, 0)
+	switch {
+	case f.Decimals != nil:
+		decimals = *f.Decimals
+		d.round(d.point+decimals, f.Rounding)
+	case f.Significant > 0:
+		d.round(f.Significant, f.Rounding)
+		decimals = max(f.Significant-d.point, 0)
+	}
+
+	integer := "0"
+	if d.point > 0 {
+		integer = groupThousands(d.digitRange(0, d.point), f.ThousandsSeparator)
+	}
+	if decimals == 0 {
+		return integer
+	}
+	return integer + "." + d.digitRange(d.point, d.point+decimals)
+}
+
+// formatExponent записывает число с порядком: 1.23e4, в инженерной записи 12.3e3
+func (d *decimal) formatExponent(f FormatOptions) string {
+	// integerDigits количество цифр мантиссы до точки при текущем порядке числа
+	integerDigits := func() int {
+		if f.Notation == NotationEngineering && !d.isZero() {
+			return ((d.point-1)%3+3)%3 + 1
+		}
+		return 1
+	}
+
+	decimals := len(d.digits) - integerDigits()
+	switch {
+	case f.Decimals != nil:
+		decimals = *f.Decimals
+		d.round(integerDigits()+decimals, f.Rounding)
+	case f.Significant > 0:
+		d.round(f.Significant, f.Rounding)
+	}
+	if f.Significant > 0 {
+		decimals = f.Significant - integerDigits()
+	}
+	decimals = max(decimals, 0)
+
+	exponent := 0
+	if !d.isZero() {
+		exponent = d.point - integerDigits()
+	}
+	mantissa := d.digitRange(0, integerDigits())
+	if decimals > 0 {
+		mantissa += "." + d.digitRange(integerDigits(), integerDigits()+decimals)
+	}
+	return mantissa + "e" + strconv.Itoa(exponent)
+}
+
+// groupThousands разделяет цифры целой части на группы по три
+func groupThousands(digits, separator string) string {
+	if separator == "" || len(digits) <= 3 {
+		return digits
+	}
+	var b strings.Builder
+	first := len(digits) % 3
+	if first == 0 {
+		first = 3
+	}
+	b.WriteString(digits[:first])
+	for i := first; i < len(digits); i += 3 {
+		b.WriteString(separator)
+		b.WriteString(digits[i : i+3])
+	}
+	return b.String()
+}
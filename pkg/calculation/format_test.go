@@ -0,0 +1,89 @@
+package calculation_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/flexer2006/y.lms_sprint1_Calc/pkg/calculation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormat(t *testing.T) {
+	decimals := func(n int) *int { return &n }
+
+	tests := []struct {
+		name     string
+		value    float64
+		options  calculation.FormatOptions
+		expected string
+	}{
+		{"shortest representation", 1.0 / 3, calculation.FormatOptions{}, "0.3333333333333333"},
+		{"integer", 42, calculation.FormatOptions{}, "42"},
+		{"decimals", math.Pi, calculation.FormatOptions{Decimals: decimals(2)}, "3.14"},
+		{"decimals pad with zeros", 1.5, calculation.FormatOptions{Decimals: decimals(3)}, "1.500"},
+		{"zero decimals", 2.5, calculation.FormatOptions{Decimals: decimals(0)}, "2"},
+		{"half even", 3.5, calculation.FormatOptions{Decimals: decimals(0)}, "4"},
+		{"half up", 2.5, calculation.FormatOptions{Decimals: decimals(0), Rounding: calculation.RoundHalfUp}, "3"},
+		{"half up decimal representation", 2.675, calculation.FormatOptions{Decimals: decimals(2), Rounding: calculation.RoundHalfUp}, "2.68"},
+		{"half up negative", -2.5, calculation.FormatOptions{Decimals: decimals(0), Rounding: calculation.RoundHalfUp}, "-3"},
+		{"half down", 2.5, calculation.FormatOptions{Decimals: decimals(0), Rounding: calculation.RoundHalfDown}, "2"},
+		{"half down above tie", 2.51, calculation.FormatOptions{Decimals: decimals(0), Rounding: calculation.RoundHalfDown}, "3"},
+		{"up", 2.1, calculation.FormatOptions{Decimals: decimals(0), Rounding: calculation.RoundUp}, "3"},
+		{"down", -2.9, calculation.FormatOptions{Decimals: decimals(0), Rounding: calculation.RoundDown}, "-2"},
+		{"ceiling", -2.9, calculation.FormatOptions{Decimals: decimals(0), Rounding: calculation.RoundCeiling}, "-2"},
+		{"floor", -2.1, calculation.FormatOptions{Decimals: decimals(0), Rounding: calculation.RoundFloor}, "-3"},
+		{"carry", 9.999, calculation.FormatOptions{Decimals: decimals(2)}, "10.00"},
+		{"round small number to zero", -0.004, calculation.FormatOptions{Decimals: decimals(2)}, "0.00"},
+		{"round small number up", 0.0004, calculation.FormatOptions{Decimals: decimals(2), Rounding: calculation.RoundUp}, "0.01"},
+		{"significant", 1234.5678, calculation.FormatOptions{Significant: 6}, "1234.57"},
+		{"significant integer part", 1234.5678, calculation.FormatOptions{Significant: 2}, "1200"},
+		{"significant small", 0.00123456, calculation.FormatOptions{Significant: 3}, "0.00123"},
+		{"significant keeps zeros", 1.5, calculation.FormatOptions{Significant: 4}, "1.500"},
+		{"thousands separator", 1234567.891, calculation.FormatOptions{Decimals: decimals(2), ThousandsSeparator: " "}, "1 234 567.89"},
+		{"thousands separator negative", -1234567, calculation.FormatOptions{ThousandsSeparator: ","}, "-1,234,567"},
+		{"scientific", 12345.678, calculation.FormatOptions{Notation: calculation.NotationScientific}, "1.2345678e4"},
+		{"scientific decimals", 12345.678, calculation.FormatOptions{Notation: calculation.NotationScientific, Decimals: decimals(2)}, "1.23e4"},
+		{"scientific small", 0.000123, calculation.FormatOptions{Notation: calculation.NotationScientific}, "1.23e-4"},
+		{"scientific carry", 9.99, calculation.FormatOptions{Notation: calculation.NotationScientific, Decimals: decimals(1)}, "1.0e1"},
+		{"scientific significant", 2, calculation.FormatOptions{Notation: calculation.NotationScientific, Significant: 3}, "2.00e0"},
+		{"scientific zero", 0, calculation.FormatOptions{Notation: calculation.NotationScientific}, "0e0"},
+		{"engineering", 12345.678, calculation.FormatOptions{Notation: calculation.NotationEngineering, Decimals: decimals(1)}, "12.3e3"},
+		{"engineering small", 0.000123, calculation.FormatOptions{Notation: calculation.NotationEngineering}, "123e-6"},
+		{"engineering carry", 999.96, calculation.FormatOptions{Notation: calculation.NotationEngineering, Decimals: decimals(1)}, "1.0e3"},
+		{"engineering significant", 12345, calculation.FormatOptions{Notation: calculation.NotationEngineering, Significant: 1}, "10e3"},
+		{"infinity", math.Inf(-1), calculation.FormatOptions{Decimals: decimals(2)}, "-Infinity"},
+		{"nan", math.NaN(), calculation.FormatOptions{}, "NaN"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := calculation.Format(tt.value, tt.options)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestFormat_InvalidOptions(t *testing.T) {
+	decimals := func(n int) *int { return &n }
+
+	tests := []struct {
+		name    string
+		options calculation.FormatOptions
+	}{
+		{"negative decimals", calculation.FormatOptions{Decimals: decimals(-1)}},
+		{"too many decimals", calculation.FormatOptions{Decimals: decimals(1000)}},
+		{"decimals and significant", calculation.FormatOptions{Decimals: decimals(2), Significant: 3}},
+		{"negative significant", calculation.FormatOptions{Significant: -1}},
+		{"unknown notation", calculation.FormatOptions{Notation: "hex"}},
+		{"unknown rounding", calculation.FormatOptions{Rounding: "random"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := calculation.Format(1, tt.options)
+			assert.Equal(t, calculation.ErrInvalidFormat, err)
+		})
+	}
+}
@@ -0,0 +1,255 @@
+package calculation
+
+import (
+	"math"
+	"math/big"
+)
+
+// function описывает встроенную функцию калькулятора
+type function struct {
+	minArgs int                                   // Минимальное количество аргументов
+	maxArgs int                                   // Максимальное количество аргументов (-1 - без ограничения)
+	call    func(args []float64) (float64, error) // Вычисление функции
+}
+
+// specialForm функция, получающая аргументы невычисленными: она сама решает,
+// когда и с какими значениями переменных их вычислять
+type specialForm func(c *Calculator, args []node) (float64, error)
+
+// constants определяет именованные константы, доступные в выражениях
+var constants = map[string]float64{
+	"pi": math.Pi,
+	"π":  math.Pi,
+	"e":  math.E,
+}
+
+// functions определяет встроенные функции калькулятора
+var functions = map[string]function{
+	"sqrt": {1, 1, func(args []float64) (float64, error) {
+		if args[0] < 0 {
+			return 0, ErrInvalidArgument
+		}
+		return math.Sqrt(args[0]), nil
+	}},
+	"abs": {1, 1, unaryFunction(math.Abs)},
+	"sin": {1, 1, unaryFunction(math.Sin)},
+	"cos": {1, 1, unaryFunction(math.Cos)},
+	"tan": {1, 1, unaryFunction(math.Tan)},
+	"asin": {1, 1, func(args []float64) (float64, error) {
+		if args[0] < -1 || args[0] > 1 {
+			return 0, ErrInvalidArgument
+		}
+		return math.Asin(args[0]), nil
+	}},
+	"acos": {1, 1, func(args []float64) (float64, error) {
+		if args[0] < -1 || args[0] > 1 {
+			return 0, ErrInvalidArgument
+		}
+		return math.Acos(args[0]), nil
+	}},
+	"atan": {1, 1, unaryFunction(math.Atan)},
+	"exp":  {1, 1, unaryFunction(math.Exp)},
+	"ln": {1, 1, func(args []float64) (float64, error) {
+		if args[0] <= 0 {
+			return 0, ErrInvalidArgument
+		}
+		return math.Log(args[0]), nil
+	}},
+	// log(x) - десятичный логарифм, log(x, b) - логарифм по основанию b
+	"log": {1, 2, func(args []float64) (float64, error) {
+		if args[0] <= 0 {
+			return 0, ErrInvalidArgument
+		}
+		if len(args) == 1 {
+			return math.Log10(args[0]), nil
+		}
+		if args[1] <= 0 || args[1] == 1 {
+			return 0, ErrInvalidArgument
+		}
+		return math.Log(args[0]) / math.Log(args[1]), nil
+	}},
+	"floor": {1, 1, unaryFunction(math.Floor)},
+	"ceil":  {1, 1, unaryFunction(math.Ceil)},
+	"round": {1, 1, unaryFunction(math.Round)},
+	"min": {1, -1, func(args []float64) (float64, error) {
+		result := args[0]
+		for _, arg := range args[1:] {
+			result = math.Min(result, arg)
+		}
+		return result, nil
+	}},
+	"max": {1, -1, func(args []float64) (float64, error) {
+		result := args[0]
+		for _, arg := range args[1:] {
+			result = math.Max(result, arg)
+		}
+		return result, nil
+	}},
+	// factorial(x) - то же, что x!; для нецелых x вычисляется через гамма-функцию
+	"factorial": {1, 1, factorial},
+	"gamma":     {1, 1, gamma},
+	"nCr":       {2, 2, combinations},
+	"nPr":       {2, 2, permutations},
+	"gcd":       {1, -1, integerFold(gcd)},
+	"lcm":       {1, -1, integerFold(lcm)},
+	"isprime": {1, 1, func(args []float64) (float64, error) {
+		n, err := integerArgument(args[0])
+		if err != nil {
+			return 0, err
+		}
+		prime, err := isPrime(big.NewInt(n))
+		if err != nil || !prime {
+			return 0, err
+		}
+		return 1, nil
+	}},
+	// mod_pow(b, e, m) - b^e mod m без переполнения промежуточных результатов
+	"mod_pow": {3, 3, func(args []float64) (float64, error) {
+		values := make([]*big.Int, len(args))
+		for i, arg := range args {
+			value, err := integerArgument(arg)
+			if err != nil {
+				return 0, err
+			}
+			values[i] = big.NewInt(value)
+		}
+		result, err := modPow(values[0], values[1], values[2])
+		if err != nil {
+			return 0, err
+		}
+		return float64(result.Int64()), nil
+	}},
+}
+
+// specialForms определяет функции с невычисленными аргументами.
+// Заполняется в init, так как формы рекурсивно вызывают eval.
+var specialForms map[string]specialForm
+
+func init() {
+	specialForms = map[string]specialForm{
+		"integrate": integrateForm,
+		"sum":       sumForm,
+		"prod":      prodForm,
+	}
+}
+
+// unaryFunction оборачивает функцию одного аргумента из пакета math
+func unaryFunction(f func(float64) float64) func(args []float64) (float64, error) {
+	return func(args []float64) (float64, error) {
+		return f(args[0]), nil
+	}
+}
+
+// withVariable вычисляет узел, временно присвоив переменной значение
+func (c *Calculator) withVariable(name string, value float64, n node) (float64, error) {
+	previous, existed := c.variables[name]
+	c.variables[name] = value
+	defer func() {
+		if existed {
+			c.variables[name] = previous
+		} else {
+			delete(c.variables, name)
+		}
+	}()
+
+	return c.eval(n)
+}
+
+// boundVariable проверяет, что аргумент специальной формы - имя переменной
+func boundVariable(arg node) (string, error) {
+	variable, ok := arg.(variableNode)
+	if !ok {
+		return "", ErrInvalidArgument
+	}
+	return variable.name, nil
+}
+
+// integrateForm вычисляет integrate(expr, var, a, b)
+func integrateForm(c *Calculator, args []node) (float64, error) {
+	if len(args) != 4 {
+		return 0, ErrArgumentCount
+	}
+	variable, err := boundVariable(args[1])
+	if err != nil {
+		return 0, err
+	}
+	a, err := c.eval(args[2])
+	if err != nil {
+		return 0, err
+	}
+	b, err := c.eval(args[3])
+	if err != nil {
+		return 0, err
+	}
+
+	integral, err := c.integrate(args[0], variable, a, b)
+	if err != nil {
+		return 0, err
+	}
+	c.integrationError += integral.ErrorEstimate
+	return integral.Value, nil
+}
+
+// sumForm вычисляет sum(expr, var, from, to)
+func sumForm(c *Calculator, args []node) (float64, error) {
+	return c.accumulate(args, 0, func(acc, value float64) float64 { return acc + value })
+}
+
+// prodForm вычисляет prod(expr, var, from, to)
+func prodForm(c *Calculator, args []node) (float64, error) {
+	return c.accumulate(args, 1, func(acc, value float64) float64 { return acc * value })
+}
+
+// maxSeriesTerms ограничивает количество слагаемых в sum и prod
+const maxSeriesTerms = 1_000_000
+
+// maxFormEvaluations ограничивает общее число вычислений подвыражений
+// специальной формы вместе с вложенными в нее формами
+const maxFormEvaluations = 1_000_000
+
+// countEvaluation учитывает вычисление подвыражения специальной формы
+func (c *Calculator) countEvaluation() error {
+	*c.evaluations++
+	if *c.evaluations > maxFormEvaluations {
+		return ErrNotConverged
+	}
+	return nil
+}
+
+// accumulate вычисляет выражение для всех целых значений переменной от from до to
+// и сворачивает результаты функцией combine
+func (c *Calculator) accumulate(args []node, initial float64, combine func(acc, value float64) float64) (float64, error) {
+	if len(args) != 4 {
+		return 0, ErrArgumentCount
+	}
+	variable, err := boundVariable(args[1])
+	if err != nil {
+		return 0, err
+	}
+	from, err := c.eval(args[2])
+	if err != nil {
+		return 0, err
+	}
+	to, err := c.eval(args[3])
+	if err != nil {
+		return 0, err
+	}
+	if from != math.Trunc(from) || to != math.Trunc(to) || to-from >= maxSeriesTerms {
+		return 0, ErrInvalidArgument
+	}
+
+	result := initial
+	sampler := c.sampler()
+	for i := from; i <= to; i++ {
+		if err := sampler.countEvaluation(); err != nil {
+			return 0, err
+		}
+		value, err := sampler.withVariable(variable, i, args[0])
+		if err != nil {
+			return 0, err
+		}
+		result = combine(result, value)
+	}
+
+	return result, nil
+}
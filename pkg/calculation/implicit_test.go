@@ -0,0 +1,63 @@
+package calculation_test
+
+import (
+	"testing"
+
+	"github.com/flexer2006/y.lms_sprint1_Calc/pkg/calculation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalc_ImplicitMultiplication(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    float64
+		expectedErr error
+	}{
+		{"number and parentheses", "2(3+4)", 14, nil},
+		{"parentheses and parentheses", "(1+2)(3+4)", 21, nil},
+		{"number and constant", "3pi", 9.42477796076938, nil},
+		{"number and variable", "2x", 10, nil},
+		{"number and function", "2 sqrt(16)", 8, nil},
+		{"variables", "x y", 15, nil},
+		{"binds tighter than division", "1/2x", 0.1, nil},
+		{"binds looser than power", "2x^2", 50, nil},
+		{"power then variable", "x^2y", 75, nil},
+		{"explicit multiplication after", "2x * 3", 30, nil},
+		{"unary minus", "-2x", -10, nil},
+		{"factorial then variable", "3!x", 30, nil},
+		{"parentheses and variable", "(x + 1)y", 18, nil},
+		{"addition", "2x + 3y", 19, nil},
+		{"variable before parentheses is a call", "x(2)", 0, calculation.ErrUnknownFunction},
+		{"numbers are not multiplied", "2 3", 0, calculation.ErrInvalidExpression},
+		{"variable named like minutes", "2m", 6, nil},
+		{"variable named like seconds", "2s + 1", 11, nil},
+		{"variable named like minutes with power", "2m^2", 18, nil},
+		{"duration suffix without variable", "hours(2h)", 2, nil},
+		{"compound duration", "hours(2h30m)", 2.5, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calc := calculation.NewCalculator()
+			calc.SetOptions(calculation.Options{ImplicitMultiplication: true})
+			calc.SetVariable("x", 5)
+			calc.SetVariable("y", 3)
+			calc.SetVariable("m", 3)
+			calc.SetVariable("s", 5)
+
+			result, err := calc.Calc(tt.input)
+			if tt.expectedErr != nil {
+				assert.Equal(t, tt.expectedErr, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.InDelta(t, tt.expected, result, 1e-9)
+		})
+	}
+}
+
+func TestCalc_ImplicitMultiplicationDisabled(t *testing.T) {
+	_, err := calculation.Calc("2(3+4)")
+	assert.Equal(t, calculation.ErrInvalidExpression, err)
+}
@@ -0,0 +1,271 @@
+package calculation
+
+import (
+	"math"
+	"math/big"
+)
+
+// maxIntegerBits ограничивает размер результатов в целочисленном режиме
+// (около 300 тысяч десятичных цифр), чтобы 10^10^10 не исчерпал память
+const maxIntegerBits = 1 << 20
+
+// integerFunction функция целочисленного режима
+type integerFunction struct {
+	minArgs int                                     // Минимальное количество аргументов
+	maxArgs int                                     // Максимальное количество аргументов (-1 - без ограничения)
+	call    func(args []*big.Int) (*big.Int, error) // Вычисление функции
+}
+
+// integerFunctions определяет функции целочисленного режима
+var integerFunctions = map[string]integerFunction{
+	"factorial": {1, 1, func(args []*big.Int) (*big.Int, error) {
+		return bigFactorial(args[0])
+	}},
+	"nCr": {2, 2, func(args []*big.Int) (*big.Int, error) {
+		n, k, err := bigCombinationArguments(args, true)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).Binomial(n, k), nil
+	}},
+	"nPr": {2, 2, func(args []*big.Int) (*big.Int, error) {
+		n, k, err := bigCombinationArguments(args, false)
+		if err != nil {
+			return nil, err
+		}
+		if k > n {
+			return new(big.Int), nil
+		}
+		return new(big.Int).MulRange(n-k+1, n), nil
+	}},
+	"gcd": {1, -1, func(args []*big.Int) (*big.Int, error) {
+		return foldIntegers(args, gcd), nil
+	}},
+	"lcm": {1, -1, func(args []*big.Int) (*big.Int, error) {
+		return checkIntegerSize(foldIntegers(args, lcm))
+	}},
+	"isprime": {1, 1, func(args []*big.Int) (*big.Int, error) {
+		prime, err := isPrime(args[0])
+		if err != nil {
+			return nil, err
+		}
+		if prime {
+			return big.NewInt(1), nil
+		}
+		return new(big.Int), nil
+	}},
+	"mod_pow": {3, 3, func(args []*big.Int) (*big.Int, error) {
+		return modPow(args[0], args[1], args[2])
+	}},
+	"abs": {1, 1, func(args []*big.Int) (*big.Int, error) {
+		return new(big.Int).Abs(args[0]), nil
+	}},
+	"min": {1, -1, func(args []*big.Int) (*big.Int, error) {
+		return foldIntegers(args, func(a, b *big.Int) *big.Int {
+			if b.Cmp(a) < 0 {
+				return b
+			}
+			return a
+		}), nil
+	}},
+	"max": {1, -1, func(args []*big.Int) (*big.Int, error) {
+		return foldIntegers(args, func(a, b *big.Int) *big.Int {
+			if b.Cmp(a) > 0 {
+				return b
+			}
+			return a
+		}), nil
+	}},
+}
+
+// CalcInteger вычисляет выражение в целых числах произвольной длины:
+// 30!, nCr(100, 50), 2^200. Деление должно быть нацело, иначе - ErrNotInteger.
+func CalcInteger(expression string) (*big.Int, error) {
+	return NewCalculator().CalcInteger(expression)
+}
+
+// CalcInteger вычисляет целочисленное выражение с учетом заданных переменных
+func (c *Calculator) CalcInteger(expression string) (*big.Int, error) {
+	root, err := c.parse(expression, parseOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return c.evalInteger(root)
+}
+
+// evalInteger вычисляет узел синтаксического дерева в целых числах
+func (c *Calculator) evalInteger(n node) (*big.Int, error) {
+	switch n := n.(type) {
+	case numberNode:
+		// Запись 1e3 тоже целое число, поэтому разбираем ее как дробь
+		value, ok := new(big.Rat).SetString(n.text)
+		if !ok || !value.IsInt() {
+			return nil, ErrNotInteger
+		}
+		return value.Num(), nil
+
+	case variableNode:
+		if value, exists := c.variables[n.name]; exists {
+			if value != math.Trunc(value) || math.IsInf(value, 0) {
+				return nil, ErrNotInteger
+			}
+			result, _ := big.NewFloat(value).Int(nil)
+			return result, nil
+		}
+		if _, exists := constants[n.name]; exists {
+			return nil, ErrNotInteger
+		}
+		return nil, ErrUnknownVariable
+
+	case unaryNode:
+		operand, err := c.evalInteger(n.operand)
+		if err != nil {
+			return nil, err
+		}
+		return new(big.Int).Neg(operand), nil
+
+	case binaryNode:
+		a, err := c.evalInteger(n.left)
+		if err != nil {
+			return nil, err
+		}
+		b, err := c.evalInteger(n.right)
+		if err != nil {
+			return nil, err
+		}
+		return applyIntegerOperation(n.op, a, b)
+
+	case callNode:
+		return c.callInteger(n)
+
+	default:
+		return nil, ErrInvalidExpression
+	}
+}
+
+// applyIntegerOperation применяет бинарную операцию к целым числам
+func applyIntegerOperation(op rune, a, b *big.Int) (*big.Int, error) {
+	switch op {
+	case '+':
+		return checkIntegerSize(new(big.Int).Add(a, b))
+
+	case '-':
+		return checkIntegerSize(new(big.Int).Sub(a, b))
+
+	case '*':
+		if a.BitLen()+b.BitLen() > maxIntegerBits+1 {
+			return nil, ErrResultTooLarge
+		}
+		return checkIntegerSize(new(big.Int).Mul(a, b))
+
+	case '/':
+		if b.Sign() == 0 {
+			return nil, ErrDivisionByZero
+		}
+		quotient, remainder := new(big.Int).QuoRem(a, b, new(big.Int))
+		if remainder.Sign() != 0 {
+			return nil, ErrNotInteger
+		}
+		return quotient, nil
+
+	case '^':
+		if b.Sign() < 0 {
+			return nil, ErrNotInteger
+		}
+		// 0, 1 и -1 в любой степени остаются малыми
+		if a.BitLen() > 1 && (!b.IsInt64() || b.Int64() > maxIntegerBits || int64(a.BitLen()-1)*b.Int64() > maxIntegerBits) {
+			return nil, ErrResultTooLarge
+		}
+		return checkIntegerSize(new(big.Int).Exp(a, b, nil))
+
+	case opPercentAdd, opPercentSub:
+		// a ± a*b/100; доля должна быть целой
+		share, err := applyIntegerOperation('*', a, b)
+		if err != nil {
+			return nil, err
+		}
+		if share, err = applyIntegerOperation('/', share, big.NewInt(100)); err != nil {
+			return nil, err
+		}
+		return applyIntegerOperation(percentBase[op], a, share)
+
+	default:
+		return nil, ErrInvalidOperator
+	}
+}
+
+// callInteger вычисляет вызов функции в целочисленном режиме
+func (c *Calculator) callInteger(n callNode) (*big.Int, error) {
+	fn, exists := integerFunctions[n.name]
+	if !exists {
+		return nil, ErrUnknownFunction
+	}
+	if len(n.args) < fn.minArgs || (fn.maxArgs >= 0 && len(n.args) > fn.maxArgs) {
+		return nil, ErrArgumentCount
+	}
+
+	args := make([]*big.Int, len(n.args))
+	for i, arg := range n.args {
+		value, err := c.evalInteger(arg)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = value
+	}
+	return fn.call(args)
+}
+
+// checkIntegerSize проверяет, что результат не превышает maxIntegerBits
+func checkIntegerSize(x *big.Int) (*big.Int, error) {
+	if x.BitLen() > maxIntegerBits {
+		return nil, ErrResultTooLarge
+	}
+	return x, nil
+}
+
+// foldIntegers сворачивает аргументы бинарной функцией
+func foldIntegers(args []*big.Int, f func(a, b *big.Int) *big.Int) *big.Int {
+	result := args[0]
+	for _, arg := range args[1:] {
+		result = f(result, arg)
+	}
+	return result
+}
+
+// maxBigFactorial наибольшее n, для которого n! укладывается в maxIntegerBits
+const maxBigFactorial = 60000
+
+// bigFactorial вычисляет n! точно
+func bigFactorial(n *big.Int) (*big.Int, error) {
+	if n.Sign() < 0 {
+		return nil, ErrInvalidArgument
+	}
+	if !n.IsInt64() || n.Int64() > maxBigFactorial {
+		return nil, ErrResultTooLarge
+	}
+	return new(big.Int).MulRange(1, n.Int64()), nil
+}
+
+// bigCombinationArguments проверяет аргументы nCr и nPr в целочисленном режиме.
+// Результат не длиннее k*log2(n) бит; для сочетаний k можно заменить на n-k.
+func bigCombinationArguments(args []*big.Int, symmetric bool) (int64, int64, error) {
+	if args[0].Sign() < 0 || args[1].Sign() < 0 {
+		return 0, 0, ErrInvalidArgument
+	}
+	if !args[0].IsInt64() || !args[1].IsInt64() {
+		return 0, 0, ErrResultTooLarge
+	}
+
+	n, k := args[0].Int64(), args[1].Int64()
+	if k > n {
+		return n, k, nil
+	}
+	factors := k
+	if symmetric && n-k < k {
+		factors = n - k
+	}
+	if n > 0 && factors > maxIntegerBits/int64(args[0].BitLen()) {
+		return 0, 0, ErrResultTooLarge
+	}
+	return n, k, nil
+}
@@ -0,0 +1,154 @@
+package calculation
+
+import "math"
+
+const (
+	integrateAbsTolerance = 1e-10 // Допустимая абсолютная погрешность интеграла
+	integrateRelTolerance = 1e-10 // Допустимая относительная погрешность интеграла
+	integrateMaxDepth     = 30    // Максимальная глубина деления отрезка
+)
+
+// Узлы и веса квадратуры Гаусса-Кронрода G7-K15 на отрезке [-1, 1].
+// Нечетные узлы Кронрода совпадают с узлами Гаусса.
+var (
+	kronrodNodes = [8]float64{
+		0.991455371120812639206854697526329,
+		0.949107912342758524526189684047851,
+		0.864864423359769072789712788640926,
+		0.741531185599394439863864773280788,
+		0.586087235467691130294144845693013,
+		0.405845151377397166906606412076961,
+		0.207784955007898467600689403773245,
+		0.000000000000000000000000000000000,
+	}
+	kronrodWeights = [8]float64{
+		0.022935322010529224963732008058970,
+		0.063092092629978553290700663189204,
+		0.104790010322250183839876322541518,
+		0.140653259715525918745189590510238,
+		0.169004726639267902826583426598550,
+		0.190350578064785409913256402421014,
+		0.204432940075298892414161999234649,
+		0.209482141084727828012999174891714,
+	}
+	gaussWeights = [4]float64{
+		0.129484966168869693270611432679082,
+		0.279705391489276667901467771423780,
+		0.381830050505118944950369775488975,
+		0.417959183673469387755102040816327,
+	}
+)
+
+// Integral результат численного интегрирования
+type Integral struct {
+	Value         float64 // Значение интеграла
+	ErrorEstimate float64 // Оценка абсолютной погрешности
+	Evaluations   int     // Количество вычислений подынтегрального выражения
+}
+
+// Integrate вычисляет определенный интеграл выражения по переменной от a до b
+// адаптивной квадратурой Гаусса-Кронрода
+func Integrate(expression, variable string, a, b float64) (*Integral, error) {
+	root, err := parse(expression, parseOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	calc := NewCalculator()
+	integral, err := calc.integrate(root, variable, a, b)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := calc.checkSpecialValue(integral.Value, false); err != nil {
+		return nil, err
+	}
+	return &integral, nil
+}
+
+// integrate интегрирует узел синтаксического дерева по переменной
+func (c *Calculator) integrate(integrand node, variable string, a, b float64) (Integral, error) {
+	if math.IsNaN(a) || math.IsNaN(b) || math.IsInf(a, 0) || math.IsInf(b, 0) {
+		return Integral{}, ErrInvalidArgument
+	}
+
+	var result Integral
+	sampler := c.sampler()
+	f := func(x float64) (float64, error) {
+		result.Evaluations++
+		if err := sampler.countEvaluation(); err != nil {
+			return 0, err
+		}
+		return sampler.withVariable(variable, x, integrand)
+	}
+
+	value, estimate, err := gaussKronrod(f, a, b)
+	if err != nil {
+		return Integral{}, err
+	}
+	result.Value, result.ErrorEstimate, err = adaptiveQuadrature(f, a, b, value, estimate, integrateMaxDepth)
+	if err != nil {
+		return Integral{}, err
+	}
+	return result, nil
+}
+
+// adaptiveQuadrature делит отрезок пополам, пока оценка погрешности не станет допустимой
+func adaptiveQuadrature(f func(float64) (float64, error), a, b, value, estimate float64, depth int) (float64, float64, error) {
+	if estimate <= math.Max(integrateAbsTolerance, integrateRelTolerance*math.Abs(value)) || depth == 0 {
+		return value, estimate, nil
+	}
+
+	middle := (a + b) / 2
+	leftValue, leftEstimate, err := gaussKronrod(f, a, middle)
+	if err != nil {
+		return 0, 0, err
+	}
+	rightValue, rightEstimate, err := gaussKronrod(f, middle, b)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	leftValue, leftEstimate, err = adaptiveQuadrature(f, a, middle, leftValue, leftEstimate, depth-1)
+	if err != nil {
+		return 0, 0, err
+	}
+	rightValue, rightEstimate, err = adaptiveQuadrature(f, middle, b, rightValue, rightEstimate, depth-1)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return leftValue + rightValue, leftEstimate + rightEstimate, nil
+}
+
+// gaussKronrod вычисляет интеграл на отрезке по формуле Кронрода, а погрешность -
+// как разницу с формулой Гаусса на тех же узлах
+func gaussKronrod(f func(float64) (float64, error), a, b float64) (float64, float64, error) {
+	center := (a + b) / 2
+	halfLength := (b - a) / 2
+
+	fCenter, err := f(center)
+	if err != nil {
+		return 0, 0, err
+	}
+	kronrod := fCenter * kronrodWeights[7]
+	gauss := fCenter * gaussWeights[3]
+
+	for i := 0; i < 7; i++ {
+		dx := halfLength * kronrodNodes[i]
+		f1, err := f(center - dx)
+		if err != nil {
+			return 0, 0, err
+		}
+		f2, err := f(center + dx)
+		if err != nil {
+			return 0, 0, err
+		}
+
+		kronrod += kronrodWeights[i] * (f1 + f2)
+		if i%2 == 1 {
+			gauss += gaussWeights[i/2] * (f1 + f2)
+		}
+	}
+
+	return kronrod * halfLength, math.Abs((kronrod - gauss) * halfLength), nil
+}
@@ -0,0 +1,99 @@
+package calculation_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/flexer2006/y.lms_sprint1_Calc/pkg/calculation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIntegrate(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		a, b       float64
+		expected   float64
+	}{
+		{"polynomial", "x^2", 0, 3, 9},
+		{"sine over period half", "sin(x)", 0, math.Pi, 2},
+		{"reversed bounds", "x", 2, 0, -2},
+		{"empty interval", "x", 1, 1, 0},
+		{"sharp peak", "1 / (1 + 10000 * x^2)", -1, 1, 0.02 * math.Atan(100)},
+		{"integrand underflows in tails", "exp(-x^2)", -30, 30, math.Sqrt(math.Pi)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			integral, err := calculation.Integrate(tt.expression, "x", tt.a, tt.b)
+			require.NoError(t, err)
+			assert.InDelta(t, tt.expected, integral.Value, 1e-9)
+			assert.Less(t, integral.ErrorEstimate, 1e-8)
+			assert.Positive(t, integral.Evaluations)
+		})
+	}
+}
+
+func TestCalc_SpecialForms(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected float64
+		err      error
+	}{
+		{"integrate", "integrate(x^2, x, 0, 3)", 9, nil},
+		{"integrate in expression", "2 * integrate(1 / x, x, 1, e) + 1", 3, nil},
+		{"sum", "sum(k^2, k, 1, 10)", 385, nil},
+		{"empty sum", "sum(k, k, 5, 1)", 0, nil},
+		{"prod", "prod(k, k, 1, 5)", 120, nil},
+		{"nested forms", "sum(prod(j, j, 1, i), i, 1, 4)", 33, nil},
+		{"bound variable does not leak", "sum(k, k, 1, 3) + k", 0, calculation.ErrUnknownVariable},
+		{"bound variable is not a name", "prod(k, 2, 1, 3)", 0, calculation.ErrInvalidArgument},
+		{"fractional bounds", "sum(k, k, 1.5, 3)", 0, calculation.ErrInvalidArgument},
+		{"wrong arity", "integrate(x, x, 0)", 0, calculation.ErrArgumentCount},
+		{"error inside integrand", "integrate(1 / (x - x), x, 0, 1)", 0, calculation.ErrDivisionByZero},
+		{"integrand underflows in tails", "integrate(exp(-x^2), x, -30, 30)", math.Sqrt(math.Pi), nil},
+		{"policy applies to form result", "sum(10^k, k, 300, 310)", 0, calculation.ErrOverflow},
+		{"oscillating integrand exceeds evaluation limit", "integrate(sin(1000*x), x, 0, 1000)", 0, calculation.ErrNotConverged},
+		{"nested forms share evaluation limit", "sum(sum(j, j, 1, 1000), k, 1, 1000)", 0, calculation.ErrNotConverged},
+		{"nested forms within evaluation limit", "sum(sum(j, j, 1, 100), k, 1, 100)", 505000, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := calculation.Calc(tt.input)
+
+			if tt.err != nil {
+				assert.Equal(t, tt.err, err)
+			} else {
+				assert.NoError(t, err)
+				assert.InDelta(t, tt.expected, result, 1e-9)
+			}
+		})
+	}
+}
+
+func TestCalc_SpecialFormsKeepOuterVariable(t *testing.T) {
+	result, err := calculation.CalcWithVariables("prod(k, k, 1, 3) * k", map[string]float64{"k": 10})
+	require.NoError(t, err)
+	assert.InDelta(t, 60, result, 1e-9)
+}
+
+func TestCalculator_IntegrationError(t *testing.T) {
+	peak, err := calculation.Integrate("1 / (1 + 10000 * x^2)", "x", -1, 1)
+	require.NoError(t, err)
+	line, err := calculation.Integrate("x", "x", 0, 1)
+	require.NoError(t, err)
+
+	calc := calculation.NewCalculator()
+	_, err = calc.Calc("integrate(1 / (1 + 10000 * x^2), x, -1, 1) + integrate(x, x, 0, 1)")
+	require.NoError(t, err)
+	assert.Positive(t, calc.IntegrationError())
+	assert.Equal(t, peak.ErrorEstimate+line.ErrorEstimate, calc.IntegrationError())
+
+	// Оценка относится к последнему выражению
+	_, err = calc.Calc("2 + 2")
+	require.NoError(t, err)
+	assert.Zero(t, calc.IntegrationError())
+}
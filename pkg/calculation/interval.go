@@ -0,0 +1,488 @@
+package calculation
+
+import (
+	"math"
+	"math/big"
+)
+
+// intervalLibraryUlps на сколько ulp расширяются результаты функций пакета math,
+// которые, в отличие от арифметики, не округляются корректно
+const intervalLibraryUlps = 2
+
+// Interval гарантированные границы значения выражения.
+// Бесконечная граница означает, что значение не ограничено с этой стороны.
+type Interval struct {
+	Lower float64 // Нижняя граница
+	Upper float64 // Верхняя граница
+}
+
+// CalcInterval вычисляет гарантированные границы значения выражения.
+// Каждая операция округляется наружу, поэтому точный результат всегда лежит
+// внутри интервала. Интервалы задаются как interval(a, b) или 3±0.1.
+// Деление на интервал, содержащий ноль, дает неограниченный интервал, а не ошибку.
+func CalcInterval(expression string) (Interval, error) {
+	return NewCalculator().CalcInterval(expression)
+}
+
+// CalcInterval вычисляет границы значения выражения с учетом заданных переменных
+func (c *Calculator) CalcInterval(expression string) (Interval, error) {
+	root, err := c.parse(expression, parseOptions{uncertainty: true})
+	if err != nil {
+		return Interval{}, err
+	}
+	return c.evalInterval(root)
+}
+
+// evalInterval вычисляет узел синтаксического дерева в интервальной арифметике
+func (c *Calculator) evalInterval(n node) (Interval, error) {
+	switch n := n.(type) {
+	case numberNode:
+		return literalInterval(n), nil
+
+	case variableNode:
+		if value, exists := c.variables[n.name]; exists {
+			return Interval{value, value}, nil
+		}
+		if value, exists := constants[n.name]; exists {
+			// Константы непредставимы точно - берем соседние числа
+			return Interval{nextDown(value), nextUp(value)}, nil
+		}
+		return Interval{}, ErrUnknownVariable
+
+	case unaryNode:
+		operand, err := c.evalInterval(n.operand)
+		if err != nil {
+			return Interval{}, err
+		}
+		return Interval{-operand.Upper, -operand.Lower}, nil
+
+	case binaryNode:
+		a, err := c.evalInterval(n.left)
+		if err != nil {
+			return Interval{}, err
+		}
+		b, err := c.evalInterval(n.right)
+		if err != nil {
+			return Interval{}, err
+		}
+		return applyIntervalOperation(n.op, a, b)
+
+	case callNode:
+		return c.callInterval(n)
+
+	default:
+		return Interval{}, ErrInvalidExpression
+	}
+}
+
+// literalInterval возвращает наименьший интервал, содержащий десятичную запись числа
+func literalInterval(n numberNode) Interval {
+	exact, ok := new(big.Rat).SetString(n.text)
+	if !ok {
+		return Interval{nextDown(n.value), nextUp(n.value)}
+	}
+
+	switch exact.Cmp(new(big.Rat).SetFloat64(n.value)) {
+	case 0:
+		return Interval{n.value, n.value}
+	case -1:
+		return Interval{nextDown(n.value), n.value}
+	default:
+		return Interval{n.value, nextUp(n.value)}
+	}
+}
+
+// applyIntervalOperation применяет бинарную операцию к интервалам
+func applyIntervalOperation(op rune, a, b Interval) (Interval, error) {
+	switch op {
+	case '+':
+		lower, _ := addBounds(a.Lower, b.Lower)
+		_, upper := addBounds(a.Upper, b.Upper)
+		return Interval{lower, upper}, nil
+
+	case '-':
+		lower, _ := addBounds(a.Lower, -b.Upper)
+		_, upper := addBounds(a.Upper, -b.Lower)
+		return Interval{lower, upper}, nil
+
+	case '*':
+		return multiplyIntervals(a, b), nil
+
+	case '/':
+		return divideIntervals(a, b)
+
+	case '^':
+		return powInterval(a, b)
+
+	case opPercentAdd, opPercentSub:
+		// a ± a*b/100; a входит дважды, поэтому интервал может быть шире точного
+		share, err := divideIntervals(multiplyIntervals(a, b), Interval{100, 100})
+		if err != nil {
+			return Interval{}, err
+		}
+		return applyIntervalOperation(percentBase[op], a, share)
+
+	case '±':
+		radius := math.Max(math.Abs(b.Lower), math.Abs(b.Upper))
+		lower, _ := addBounds(a.Lower, -radius)
+		_, upper := addBounds(a.Upper, radius)
+		return Interval{lower, upper}, nil
+
+	default:
+		return Interval{}, ErrInvalidOperator
+	}
+}
+
+// multiplyIntervals перемножает интервалы, выбирая крайние из произведений границ
+func multiplyIntervals(a, b Interval) Interval {
+	result := Interval{math.Inf(1), math.Inf(-1)}
+	for _, x := range [2]float64{a.Lower, a.Upper} {
+		for _, y := range [2]float64{b.Lower, b.Upper} {
+			lower, upper := mulBounds(x, y)
+			result.Lower = math.Min(result.Lower, lower)
+			result.Upper = math.Max(result.Upper, upper)
+		}
+	}
+	return result
+}
+
+// divideIntervals делит интервалы; если делитель содержит ноль, результат
+// неограничен с одной или обеих сторон (оболочка объединения двух лучей)
+func divideIntervals(a, b Interval) (Interval, error) {
+	entire := Interval{math.Inf(-1), math.Inf(1)}
+
+	switch {
+	case b.Lower > 0 || b.Upper < 0:
+		result := Interval{math.Inf(1), math.Inf(-1)}
+		for _, x := range [2]float64{a.Lower, a.Upper} {
+			for _, y := range [2]float64{b.Lower, b.Upper} {
+				lower, upper := divBounds(x, y)
+				result.Lower = math.Min(result.Lower, lower)
+				result.Upper = math.Max(result.Upper, upper)
+			}
+		}
+		return result, nil
+
+	case b.Lower == 0 && b.Upper == 0:
+		// Делитель - ровно ноль: множество результатов пусто
+		return Interval{}, ErrEmptyInterval
+
+	case a.Lower <= 0 && a.Upper >= 0:
+		return entire, nil
+
+	case a.Upper < 0 && b.Upper == 0:
+		lower, _ := divBounds(a.Upper, b.Lower)
+		return Interval{lower, math.Inf(1)}, nil
+
+	case a.Upper < 0 && b.Lower == 0:
+		_, upper := divBounds(a.Upper, b.Upper)
+		return Interval{math.Inf(-1), upper}, nil
+
+	case a.Lower > 0 && b.Upper == 0:
+		_, upper := divBounds(a.Lower, b.Lower)
+		return Interval{math.Inf(-1), upper}, nil
+
+	case a.Lower > 0 && b.Lower == 0:
+		lower, _ := divBounds(a.Lower, b.Upper)
+		return Interval{lower, math.Inf(1)}, nil
+
+	default:
+		// Ноль строго внутри делителя: результат - два луча, возвращаем их оболочку
+		return entire, nil
+	}
+}
+
+// powInterval возводит интервал в степень
+func powInterval(a, b Interval) (Interval, error) {
+	if b.Lower == b.Upper && b.Lower == math.Trunc(b.Lower) {
+		n := b.Lower
+		switch {
+		case n == 0:
+			return Interval{1, 1}, nil
+		case n < 0:
+			positive, err := powInterval(a, Interval{-n, -n})
+			if err != nil {
+				return Interval{}, err
+			}
+			return divideIntervals(Interval{1, 1}, positive)
+		case math.Mod(n, 2) == 1 || a.Lower >= 0:
+			return monotonePow(a.Lower, a.Upper, n), nil
+		case a.Upper <= 0:
+			return monotonePow(-a.Upper, -a.Lower, n), nil
+		default:
+			upper := math.Max(monotonePow(0, -a.Lower, n).Upper, monotonePow(0, a.Upper, n).Upper)
+			return Interval{0, upper}, nil
+		}
+	}
+
+	// Дробная степень определена только для неотрицательного основания
+	if a.Lower < 0 || (a.Lower == 0 && b.Lower <= 0) {
+		return Interval{}, ErrInvalidArgument
+	}
+
+	result := Interval{math.Inf(1), math.Inf(-1)}
+	for _, x := range [2]float64{a.Lower, a.Upper} {
+		for _, y := range [2]float64{b.Lower, b.Upper} {
+			lower, upper := widen(math.Pow(x, y))
+			result.Lower = math.Min(result.Lower, lower)
+			result.Upper = math.Max(result.Upper, upper)
+		}
+	}
+	result.Lower = math.Max(result.Lower, 0)
+	return result, nil
+}
+
+// monotonePow возводит в степень n интервал, на котором x^n возрастает
+func monotonePow(lower, upper, n float64) Interval {
+	low, _ := widen(math.Pow(lower, n))
+	_, high := widen(math.Pow(upper, n))
+	if lower >= 0 {
+		low = math.Max(low, 0)
+	}
+	return Interval{low, high}
+}
+
+// callInterval вычисляет встроенную функцию над интервалом
+func (c *Calculator) callInterval(n callNode) (Interval, error) {
+	args := make([]Interval, len(n.args))
+	for i, arg := range n.args {
+		value, err := c.evalInterval(arg)
+		if err != nil {
+			return Interval{}, err
+		}
+		args[i] = value
+	}
+
+	if n.name == "interval" {
+		// interval(a, b) - интервал от нижней границы a до верхней границы b
+		if len(args) != 2 {
+			return Interval{}, ErrArgumentCount
+		}
+		if args[0].Lower > args[1].Upper {
+			return Interval{}, ErrInvalidArgument
+		}
+		return Interval{args[0].Lower, args[1].Upper}, nil
+	}
+
+	fn, exists := functions[n.name]
+	if !exists {
+		return Interval{}, ErrUnknownFunction
+	}
+	if len(args) < fn.minArgs || (fn.maxArgs >= 0 && len(args) > fn.maxArgs) {
+		return Interval{}, ErrArgumentCount
+	}
+	x := args[0]
+
+	switch n.name {
+	case "sqrt":
+		if x.Lower < 0 {
+			return Interval{}, ErrInvalidArgument
+		}
+		lower, _ := sqrtBounds(x.Lower)
+		_, upper := sqrtBounds(x.Upper)
+		return Interval{lower, upper}, nil
+
+	case "abs":
+		switch {
+		case x.Lower >= 0:
+			return x, nil
+		case x.Upper <= 0:
+			return Interval{-x.Upper, -x.Lower}, nil
+		default:
+			return Interval{0, math.Max(-x.Lower, x.Upper)}, nil
+		}
+
+	case "exp", "atan":
+		return increasing(fn, x), nil
+
+	case "floor", "ceil", "round":
+		lower, _ := fn.call([]float64{x.Lower})
+		upper, _ := fn.call([]float64{x.Upper})
+		return Interval{lower, upper}, nil
+
+	case "asin", "acos":
+		if x.Lower < -1 || x.Upper > 1 {
+			return Interval{}, ErrInvalidArgument
+		}
+		if n.name == "acos" {
+			result := increasing(fn, Interval{x.Upper, x.Lower})
+			return Interval{math.Max(result.Lower, 0), math.Min(result.Upper, nextUp(math.Pi))}, nil
+		}
+		return increasing(fn, x), nil
+
+	case "ln", "log":
+		if x.Lower <= 0 {
+			return Interval{}, ErrInvalidArgument
+		}
+		logarithm := increasing(function{call: unaryFunction(math.Log)}, x)
+		if n.name == "ln" {
+			return logarithm, nil
+		}
+		base := Interval{10, 10}
+		if len(args) == 2 {
+			base = args[1]
+		}
+		if base.Lower <= 0 || (base.Lower <= 1 && base.Upper >= 1) {
+			return Interval{}, ErrInvalidArgument
+		}
+		return divideIntervals(logarithm, increasing(function{call: unaryFunction(math.Log)}, base))
+
+	case "min", "max":
+		result := x
+		for _, arg := range args[1:] {
+			if n.name == "min" {
+				result = Interval{math.Min(result.Lower, arg.Lower), math.Min(result.Upper, arg.Upper)}
+			} else {
+				result = Interval{math.Max(result.Lower, arg.Lower), math.Max(result.Upper, arg.Upper)}
+			}
+		}
+		return result, nil
+
+	case "sin":
+		return periodicInterval(math.Sin, x, math.Pi/2), nil
+
+	case "cos":
+		return periodicInterval(math.Cos, x, 0), nil
+
+	case "tan":
+		// Полюс внутри интервала - значение не ограничено
+		if containsCriticalPoint(x, math.Pi/2, math.Pi) {
+			return Interval{math.Inf(-1), math.Inf(1)}, nil
+		}
+		return increasing(fn, x), nil
+
+	default:
+		return Interval{}, ErrUnknownFunction
+	}
+}
+
+// increasing применяет возрастающую функцию к границам интервала
+func increasing(fn function, x Interval) Interval {
+	lower, _ := fn.call([]float64{x.Lower})
+	upper, _ := fn.call([]float64{x.Upper})
+	low, _ := widen(lower)
+	_, high := widen(upper)
+	return Interval{low, high}
+}
+
+// periodicInterval находит образ интервала для sin или cos: максимум 1 достигается
+// в точках maximum + 2kπ, минимум -1 - в точках maximum + π + 2kπ
+func periodicInterval(f func(float64) float64, x Interval, maximum float64) Interval {
+	if math.IsInf(x.Lower, 0) || math.IsInf(x.Upper, 0) || x.Upper-x.Lower >= 2*math.Pi {
+		return Interval{-1, 1}
+	}
+
+	low, _ := widen(math.Min(f(x.Lower), f(x.Upper)))
+	_, high := widen(math.Max(f(x.Lower), f(x.Upper)))
+	if containsCriticalPoint(x, maximum, 2*math.Pi) {
+		high = 1
+	}
+	if containsCriticalPoint(x, maximum+math.Pi, 2*math.Pi) {
+		low = -1
+	}
+	return Interval{math.Max(low, -1), math.Min(high, 1)}
+}
+
+// containsCriticalPoint проверяет, содержит ли интервал точку point + k*period.
+// Точки вблизи границ считаются попавшими, чтобы погрешность π не сузила результат.
+func containsCriticalPoint(x Interval, point, period float64) bool {
+	slack := 1e-12 * math.Max(1, math.Max(math.Abs(x.Lower), math.Abs(x.Upper)))
+	k := math.Ceil((x.Lower - slack - point) / period)
+	return point+k*period <= x.Upper+slack
+}
+
+// nextDown возвращает ближайшее меньшее число
+func nextDown(x float64) float64 {
+	return math.Nextafter(x, math.Inf(-1))
+}
+
+// nextUp возвращает ближайшее большее число
+func nextUp(x float64) float64 {
+	return math.Nextafter(x, math.Inf(1))
+}
+
+// widen расширяет результат библиотечной функции на intervalLibraryUlps с каждой стороны
+func widen(x float64) (float64, float64) {
+	lower, upper := x, x
+	for i := 0; i < intervalLibraryUlps; i++ {
+		lower, upper = nextDown(lower), nextUp(upper)
+	}
+	return lower, upper
+}
+
+// directed возвращает округления вниз и вверх для приближения x,
+// если известен знак его ошибки: точное значение равно x + err
+func directed(x, err float64) (float64, float64) {
+	switch {
+	case err > 0:
+		return x, nextUp(x)
+	case err < 0:
+		return nextDown(x), x
+	default:
+		return x, x
+	}
+}
+
+// inexact обрабатывает переполнение и область денормализованных чисел,
+// где безошибочные преобразования неприменимы
+func inexact(x float64, finiteOperands bool) (float64, float64, bool) {
+	switch {
+	case math.IsInf(x, 1) && finiteOperands:
+		return math.MaxFloat64, x, true
+	case math.IsInf(x, -1) && finiteOperands:
+		return x, -math.MaxFloat64, true
+	case math.IsInf(x, 0) || math.IsNaN(x):
+		return x, x, true
+	case x != 0 && math.Abs(x) < 0x1p-969:
+		return nextDown(x), nextUp(x), true
+	default:
+		return 0, 0, false
+	}
+}
+
+// addBounds возвращает округления суммы вниз и вверх (ошибка по алгоритму TwoSum)
+func addBounds(a, b float64) (float64, float64) {
+	s := a + b
+	if lower, upper, ok := inexact(s, !math.IsInf(a, 0) && !math.IsInf(b, 0)); ok {
+		return lower, upper
+	}
+	bb := s - a
+	return directed(s, (a-(s-bb))+(b-bb))
+}
+
+// mulBounds возвращает округления произведения вниз и вверх (ошибка через FMA)
+func mulBounds(a, b float64) (float64, float64) {
+	if a == 0 || b == 0 {
+		return 0, 0
+	}
+	p := a * b
+	if lower, upper, ok := inexact(p, !math.IsInf(a, 0) && !math.IsInf(b, 0)); ok {
+		return lower, upper
+	}
+	return directed(p, math.FMA(a, b, -p))
+}
+
+// divBounds возвращает округления частного вниз и вверх (остаток через FMA)
+func divBounds(a, b float64) (float64, float64) {
+	q := a / b
+	if math.IsInf(b, 0) && !math.IsInf(a, 0) {
+		return q, q
+	}
+	if lower, upper, ok := inexact(q, !math.IsInf(a, 0)); ok {
+		return lower, upper
+	}
+	remainder := math.FMA(-q, b, a)
+	if b < 0 {
+		remainder = -remainder
+	}
+	return directed(q, remainder)
+}
+
+// sqrtBounds возвращает округления корня вниз и вверх
+func sqrtBounds(x float64) (float64, float64) {
+	r := math.Sqrt(x)
+	if math.IsInf(r, 0) || r == 0 {
+		return r, r
+	}
+	return directed(r, -math.FMA(r, r, -x))
+}
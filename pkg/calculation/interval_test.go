@@ -0,0 +1,91 @@
+package calculation_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/flexer2006/y.lms_sprint1_Calc/pkg/calculation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalcInterval(t *testing.T) {
+	inf := math.Inf(1)
+
+	tests := []struct {
+		name         string
+		input        string
+		lower, upper float64
+		err          error
+	}{
+		{"exact integers", "2 + 3 * 4", 14, 14, nil},
+		{"interval literal", "interval(1, 2) + interval(10, 20)", 11, 22, nil},
+		{"plus-minus", "10±0.5 * 2", 19, 21, nil},
+		{"subtraction", "interval(1, 2) - interval(0, 5)", -4, 2, nil},
+		{"multiplication with signs", "interval(-2, 3) * interval(-1, 4)", -8, 12, nil},
+		{"even power with zero", "interval(-3, 2)^2", 0, 9, nil},
+		{"odd power", "interval(-3, 2)^3", -27, 8, nil},
+		{"division without zero", "1 / interval(2, 4)", 0.25, 0.5, nil},
+		{"divisor touching zero from above", "1 / interval(0, 2)", 0.5, inf, nil},
+		{"divisor touching zero from below", "1 / interval(-2, 0)", -inf, -0.5, nil},
+		{"divisor with zero inside", "1 / interval(-1, 1)", -inf, inf, nil},
+		{"both contain zero", "interval(-1, 1) / interval(-1, 1)", -inf, inf, nil},
+		{"negative power through zero", "interval(-1, 1)^-2", 1, inf, nil},
+		{"sine over maximum", "sin(interval(0, 3))", 0, 1, nil},
+		{"cosine over full period", "cos(interval(0, 7))", -1, 1, nil},
+		{"absolute value", "abs(interval(-5, 3))", 0, 5, nil},
+		{"division by exact zero", "1 / 0", 0, 0, calculation.ErrEmptyInterval},
+		{"sqrt of negative", "sqrt(interval(-1, 4))", 0, 0, calculation.ErrInvalidArgument},
+		{"inverted interval", "interval(2, 1)", 0, 0, calculation.ErrInvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := calculation.CalcInterval(tt.input)
+
+			if tt.err != nil {
+				assert.Equal(t, tt.err, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.LessOrEqual(t, result.Lower, tt.lower)
+			assert.GreaterOrEqual(t, result.Upper, tt.upper)
+			if !math.IsInf(tt.lower, 0) {
+				assert.InDelta(t, tt.lower, result.Lower, 1e-9)
+			} else {
+				assert.True(t, math.IsInf(result.Lower, -1))
+			}
+			if !math.IsInf(tt.upper, 0) {
+				assert.InDelta(t, tt.upper, result.Upper, 1e-9)
+			} else {
+				assert.True(t, math.IsInf(result.Upper, 1))
+			}
+		})
+	}
+}
+
+func TestCalcInterval_DirectedRounding(t *testing.T) {
+	// 0.1 непредставимо точно: интервал должен строго содержать 0.3
+	result, err := calculation.CalcInterval("0.1 + 0.2")
+	require.NoError(t, err)
+	assert.Less(t, result.Lower, 0.3)
+	assert.Greater(t, result.Upper, 0.3)
+	assert.Less(t, result.Upper-result.Lower, 1e-15)
+
+	// Точные операции не расширяют интервал
+	result, err = calculation.CalcInterval("0.5 * 4 + 0.25")
+	require.NoError(t, err)
+	assert.Equal(t, calculation.Interval{Lower: 2.25, Upper: 2.25}, result)
+
+	// Образ pi содержит точное значение
+	result, err = calculation.CalcInterval("sin(pi)")
+	require.NoError(t, err)
+	assert.LessOrEqual(t, result.Lower, 0.0)
+	assert.GreaterOrEqual(t, result.Upper, 0.0)
+
+	// Переполнение не превращает нижнюю границу в бесконечность
+	result, err = calculation.CalcInterval("1e308 * 10")
+	require.NoError(t, err)
+	assert.Equal(t, math.MaxFloat64, result.Lower)
+	assert.True(t, math.IsInf(result.Upper, 1))
+}
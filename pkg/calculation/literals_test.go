@@ -0,0 +1,93 @@
+package calculation_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/flexer2006/y.lms_sprint1_Calc/pkg/calculation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalc_NumericLiterals(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected float64
+	}{
+		{"subtraction is not a literal", "2-3", -1},
+		{"negative exponent", "1.5e-3", 0.0015},
+		{"positive exponent", "2E+10", 2e10},
+		{"exponent without sign", "3e2", 300},
+		{"exponent then subtraction", "1e2-1", 99},
+		{"leading dot", ".5", 0.5},
+		{"leading dot with exponent", ".5e1", 5},
+		{"trailing dot", "2. + 1", 3},
+		{"underscores", "1_000.000_1", 1000.0001},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := calculation.Calc(tt.input)
+			require.NoError(t, err)
+			assert.InDelta(t, tt.expected, result, 1e-12)
+		})
+	}
+}
+
+func TestCalc_MalformedLiterals(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		position int
+	}{
+		{"exponent without digits", "1e", 1},
+		{"exponent sign without digits", "2 * 1e+", 5},
+		{"exponent before parenthesis", "(1e)", 2},
+		{"second decimal point", "1.2.3", 3},
+		{"double dot", "1..2", 2},
+		{"lone dot", "2 + .", 4},
+		{"trailing underscore", "1_ + 2", 1},
+		{"double underscore", "1__000", 1},
+		{"out of range", "1e400", 0},
+		{"position counts characters", "√4 + 1.2.3", 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := calculation.Calc(tt.input)
+			require.Error(t, err)
+			assert.True(t, errors.Is(err, calculation.ErrInvalidNumber), "unexpected error %v", err)
+
+			var syntaxErr *calculation.SyntaxError
+			require.True(t, errors.As(err, &syntaxErr))
+			assert.Equal(t, tt.position, syntaxErr.Position)
+		})
+	}
+}
+
+func FuzzCalc(f *testing.F) {
+	seeds := []string{
+		"2 + 2 * 2", "1.5e-3", ".5", "1e", "1.2.3", "2-3", "((1)", "[1, 2; 3, 4] * [5; 6]",
+		"integrate(x^2, x, 0, 1)", "sum(k, k, 1, 10)", "mean([3, 5, 8])", "5!", "nCr(10, 3)",
+		`date("2026-10-17") + 30d`, "2h30m * 3", "200 + 15%", "2(3+4)", "1 000,5", "√16 + 3²",
+		"10⁻³", "3.2±0.1", "5 km + 300 m", "interval(1, 2) / 3", "\xff", "1_000", "x(",
+	}
+	for _, seed := range seeds {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, expression string) {
+		calc := calculation.NewCalculator()
+		calc.SetOptions(calculation.Options{Percent: true, ImplicitMultiplication: true, Locale: "ru-RU"})
+
+		// Проверяется только отсутствие паники во всех режимах
+		_, _ = calculation.Calc(expression)
+		_, _ = calculation.Evaluate(expression)
+		_, _ = calc.Evaluate(expression)
+		_, _ = calc.CalcQuantity(expression)
+		_, _ = calc.CalcUncertain(expression)
+		_, _ = calc.CalcInterval(expression)
+		_, _ = calc.CalcInteger(expression)
+	})
+}
@@ -0,0 +1,298 @@
+package calculation
+
+import "math"
+
+// singularTolerance относительный порог ведущего элемента, ниже которого матрица считается вырожденной
+const singularTolerance = 1e-12
+
+// Matrix матрица чисел; вектор - матрица из одной строки или одного столбца
+type Matrix struct {
+	Rows int       // Количество строк
+	Cols int       // Количество столбцов
+	Data []float64 // Элементы по строкам
+}
+
+func (*Matrix) isValue() {}
+
+// NewMatrix создает матрицу из строк одинаковой длины
+func NewMatrix(rows [][]float64) (*Matrix, error) {
+	if len(rows) == 0 || len(rows[0]) == 0 {
+		return nil, ErrDimensionMismatch
+	}
+
+	m := newMatrix(len(rows), len(rows[0]))
+	for i, row := range rows {
+		if len(row) != m.Cols {
+			return nil, ErrDimensionMismatch
+		}
+		copy(m.Data[i*m.Cols:], row)
+	}
+	return m, nil
+}
+
+// newMatrix создает нулевую матрицу заданного размера
+func newMatrix(rows, cols int) *Matrix {
+	return &Matrix{Rows: rows, Cols: cols, Data: make([]float64, rows*cols)}
+}
+
+// identity создает единичную матрицу
+func identity(n int) *Matrix {
+	m := newMatrix(n, n)
+	for i := 0; i < n; i++ {
+		m.Data[i*n+i] = 1
+	}
+	return m
+}
+
+// At возвращает элемент в строке i и столбце j
+func (m *Matrix) At(i, j int) float64 {
+	return m.Data[i*m.Cols+j]
+}
+
+// ToRows возвращает элементы матрицы по строкам
+func (m *Matrix) ToRows() [][]float64 {
+	rows := make([][]float64, m.Rows)
+	for i := range rows {
+		rows[i] = append([]float64(nil), m.Data[i*m.Cols:(i+1)*m.Cols]...)
+	}
+	return rows
+}
+
+// isVector проверяет, что матрица - вектор-строка или вектор-столбец
+func (m *Matrix) isVector() bool {
+	return m.Rows == 1 || m.Cols == 1
+}
+
+// transpose транспонирует матрицу
+func (m *Matrix) transpose() *Matrix {
+	result := newMatrix(m.Cols, m.Rows)
+	for i := 0; i < m.Rows; i++ {
+		for j := 0; j < m.Cols; j++ {
+			result.Data[j*m.Rows+i] = m.At(i, j)
+		}
+	}
+	return result
+}
+
+// mapElements применяет функцию к каждому элементу матрицы
+func (m *Matrix) mapElements(f func(float64) (float64, error)) (*Matrix, error) {
+	result := newMatrix(m.Rows, m.Cols)
+	for i, value := range m.Data {
+		mapped, err := f(value)
+		if err != nil {
+			return nil, err
+		}
+		result.Data[i] = mapped
+	}
+	return result, nil
+}
+
+// elementwise применяет операцию к соответствующим элементам; число
+// распространяется на все элементы матрицы
+func elementwise(op func(a, b float64) (float64, error), a, b Value) (Value, error) {
+	switch a := a.(type) {
+	case Number:
+		switch b := b.(type) {
+		case Number:
+			result, err := op(float64(a), float64(b))
+			return Number(result), err
+		case *Matrix:
+			return b.mapElements(func(x float64) (float64, error) { return op(float64(a), x) })
+		}
+	case *Matrix:
+		switch b := b.(type) {
+		case Number:
+			return a.mapElements(func(x float64) (float64, error) { return op(x, float64(b)) })
+		case *Matrix:
+			if a.Rows != b.Rows || a.Cols != b.Cols {
+				return nil, ErrDimensionMismatch
+			}
+			result := newMatrix(a.Rows, a.Cols)
+			for i := range a.Data {
+				value, err := op(a.Data[i], b.Data[i])
+				if err != nil {
+					return nil, err
+				}
+				result.Data[i] = value
+			}
+			return result, nil
+		}
+	}
+	return nil, ErrInvalidArgument
+}
+
+// multiplyMatrices вычисляет матричное произведение
+func multiplyMatrices(a, b *Matrix) (*Matrix, error) {
+	if a.Cols != b.Rows {
+		return nil, ErrDimensionMismatch
+	}
+
+	result := newMatrix(a.Rows, b.Cols)
+	for i := 0; i < a.Rows; i++ {
+		for k := 0; k < a.Cols; k++ {
+			aik := a.At(i, k)
+			for j := 0; j < b.Cols; j++ {
+				result.Data[i*b.Cols+j] += aik * b.At(k, j)
+			}
+		}
+	}
+	return result, nil
+}
+
+// luDecompose раскладывает квадратную матрицу методом Гаусса с выбором ведущего
+// элемента; возвращает верхнетреугольную часть, знак перестановки и признак вырожденности
+func (m *Matrix) luDecompose() (*Matrix, float64, bool) {
+	n := m.Rows
+	lu := &Matrix{Rows: n, Cols: n, Data: append([]float64(nil), m.Data...)}
+	sign := 1.0
+
+	scale := 0.0
+	for _, value := range m.Data {
+		scale = math.Max(scale, math.Abs(value))
+	}
+
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(lu.At(row, col)) > math.Abs(lu.At(pivot, col)) {
+				pivot = row
+			}
+		}
+		if math.Abs(lu.At(pivot, col)) <= singularTolerance*scale || scale == 0 {
+			return lu, 0, true
+		}
+		if pivot != col {
+			for j := 0; j < n; j++ {
+				lu.Data[col*n+j], lu.Data[pivot*n+j] = lu.Data[pivot*n+j], lu.Data[col*n+j]
+			}
+			sign = -sign
+		}
+
+		for row := col + 1; row < n; row++ {
+			factor := lu.At(row, col) / lu.At(col, col)
+			for j := col; j < n; j++ {
+				lu.Data[row*n+j] -= factor * lu.At(col, j)
+			}
+		}
+	}
+
+	return lu, sign, false
+}
+
+// determinant вычисляет определитель квадратной матрицы
+func (m *Matrix) determinant() (float64, error) {
+	if m.Rows != m.Cols {
+		return 0, ErrDimensionMismatch
+	}
+
+	lu, sign, singular := m.luDecompose()
+	if singular {
+		return 0, nil
+	}
+	det := sign
+	for i := 0; i < m.Rows; i++ {
+		det *= lu.At(i, i)
+	}
+	return det, nil
+}
+
+// inverse находит обратную матрицу методом Гаусса-Жордана
+func (m *Matrix) inverse() (*Matrix, error) {
+	if m.Rows != m.Cols {
+		return nil, ErrDimensionMismatch
+	}
+	if _, _, singular := m.luDecompose(); singular {
+		return nil, ErrSingularMatrix
+	}
+
+	n := m.Rows
+	work := &Matrix{Rows: n, Cols: n, Data: append([]float64(nil), m.Data...)}
+	result := identity(n)
+
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(work.At(row, col)) > math.Abs(work.At(pivot, col)) {
+				pivot = row
+			}
+		}
+		for j := 0; j < n; j++ {
+			work.Data[col*n+j], work.Data[pivot*n+j] = work.Data[pivot*n+j], work.Data[col*n+j]
+			result.Data[col*n+j], result.Data[pivot*n+j] = result.Data[pivot*n+j], result.Data[col*n+j]
+		}
+
+		divisor := work.At(col, col)
+		for j := 0; j < n; j++ {
+			work.Data[col*n+j] /= divisor
+			result.Data[col*n+j] /= divisor
+		}
+
+		for row := 0; row < n; row++ {
+			if row == col {
+				continue
+			}
+			factor := work.At(row, col)
+			for j := 0; j < n; j++ {
+				work.Data[row*n+j] -= factor * work.At(col, j)
+				result.Data[row*n+j] -= factor * result.At(col, j)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// power возводит квадратную матрицу в целую степень; отрицательная степень - через обратную
+func (m *Matrix) power(exponent float64) (*Matrix, error) {
+	if m.Rows != m.Cols {
+		return nil, ErrDimensionMismatch
+	}
+	if exponent != math.Trunc(exponent) || math.Abs(exponent) > math.MaxInt32 {
+		return nil, ErrInvalidArgument
+	}
+
+	base := m
+	if exponent < 0 {
+		inverse, err := m.inverse()
+		if err != nil {
+			return nil, err
+		}
+		base, exponent = inverse, -exponent
+	}
+
+	result := identity(m.Rows)
+	for n := int64(exponent); n > 0; n >>= 1 {
+		if n&1 == 1 {
+			result, _ = multiplyMatrices(result, base)
+		}
+		base, _ = multiplyMatrices(base, base)
+	}
+	return result, nil
+}
+
+// dot вычисляет скалярное произведение векторов одинаковой длины
+func dot(a, b *Matrix) (float64, error) {
+	if !a.isVector() || !b.isVector() || len(a.Data) != len(b.Data) {
+		return 0, ErrDimensionMismatch
+	}
+
+	sum := 0.0
+	for i := range a.Data {
+		sum += a.Data[i] * b.Data[i]
+	}
+	return sum, nil
+}
+
+// cross вычисляет векторное произведение трехмерных векторов
+func cross(a, b *Matrix) (*Matrix, error) {
+	if !a.isVector() || !b.isVector() || len(a.Data) != 3 || len(b.Data) != 3 {
+		return nil, ErrDimensionMismatch
+	}
+
+	result := &Matrix{Rows: a.Rows, Cols: a.Cols, Data: []float64{
+		a.Data[1]*b.Data[2] - a.Data[2]*b.Data[1],
+		a.Data[2]*b.Data[0] - a.Data[0]*b.Data[2],
+		a.Data[0]*b.Data[1] - a.Data[1]*b.Data[0],
+	}}
+	return result, nil
+}
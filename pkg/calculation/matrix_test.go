@@ -0,0 +1,94 @@
+package calculation_test
+
+import (
+	"testing"
+
+	"github.com/flexer2006/y.lms_sprint1_Calc/pkg/calculation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvaluate_Matrices(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected [][]float64
+	}{
+		{"literal", "[1, 2; 3, 4]", [][]float64{{1, 2}, {3, 4}}},
+		{"column vector", "[1; 2; 3]", [][]float64{{1}, {2}, {3}}},
+		{"element expressions", "[1 + 1, 2 * 3]", [][]float64{{2, 6}}},
+		{"addition", "[1, 2; 3, 4] + [10, 20; 30, 40]", [][]float64{{11, 22}, {33, 44}}},
+		{"scalar broadcast", "[1, 2] * 3 - 1", [][]float64{{2, 5}}},
+		{"matrix product", "[1, 2; 3, 4] * [5; 6]", [][]float64{{17}, {39}}},
+		{"elementwise product", "[1, 2; 3, 4] .* [5, 6; 7, 8]", [][]float64{{5, 12}, {21, 32}}},
+		{"elementwise division", "[2, 9] ./ [1, 3]", [][]float64{{2, 3}}},
+		{"elementwise power", "[1, 2, 3] .^ 2", [][]float64{{1, 4, 9}}},
+		{"matrix power", "[1, 1; 1, 0] ^ 5", [][]float64{{8, 5}, {5, 3}}},
+		{"transpose", "transpose([1, 2, 3])", [][]float64{{1}, {2}, {3}}},
+		{"inverse", "inv([4, 7; 2, 6])", [][]float64{{0.6, -0.7}, {-0.2, 0.4}}},
+		{"division by matrix", "[4, 7; 2, 6] / [4, 7; 2, 6]", [][]float64{{1, 0}, {0, 1}}},
+		{"negative power", "[2, 0; 0, 4] ^ -1", [][]float64{{0.5, 0}, {0, 0.25}}},
+		{"cross product", "cross([1, 0, 0], [0, 1, 0])", [][]float64{{0, 0, 1}}},
+		{"unary minus", "-[1, -2]", [][]float64{{-1, 2}}},
+		{"elementwise function", "sqrt([4, 9])", [][]float64{{2, 3}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := calculation.Evaluate(tt.input)
+			require.NoError(t, err)
+
+			matrix, ok := result.(*calculation.Matrix)
+			require.True(t, ok, "expected matrix, got %T", result)
+			rows := matrix.ToRows()
+			require.Len(t, rows, len(tt.expected))
+			for i := range rows {
+				assert.InDeltaSlice(t, tt.expected[i], rows[i], 1e-9)
+			}
+		})
+	}
+}
+
+func TestEvaluate_Scalars(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected float64
+		err      error
+	}{
+		{"plain number", "2 + 2", 4, nil},
+		{"determinant", "det([1, 2; 3, 4])", -2, nil},
+		{"singular determinant", "det([1, 2; 2, 4])", 0, nil},
+		{"dot product", "dot([1, 2, 3], [4, 5, 6])", 32, nil},
+		{"row times column", "det([1, 2, 3] * [4; 5; 6])", 32, nil},
+		{"special form", "sum(k, k, 1, 4)", 10, nil},
+		{"ragged rows", "[1, 2; 3]", 0, calculation.ErrDimensionMismatch},
+		{"shape mismatch", "[1, 2] + [1, 2, 3]", 0, calculation.ErrDimensionMismatch},
+		{"product shape mismatch", "[1, 2] * [1, 2]", 0, calculation.ErrDimensionMismatch},
+		{"singular inverse", "inv([1, 2; 2, 4])", 0, calculation.ErrSingularMatrix},
+		{"non-square determinant", "det([1, 2, 3])", 0, calculation.ErrDimensionMismatch},
+		{"nested matrix", "[[1, 2], 3]", 0, calculation.ErrNotScalar},
+		{"unclosed bracket", "[1, 2", 0, calculation.ErrMismatchedParens},
+		{"empty matrix", "[]", 0, calculation.ErrInvalidExpression},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := calculation.Evaluate(tt.input)
+
+			if tt.err != nil {
+				assert.Equal(t, tt.err, err)
+				return
+			}
+			require.NoError(t, err)
+			number, ok := result.(calculation.Number)
+			require.True(t, ok, "expected number, got %T", result)
+			assert.InDelta(t, tt.expected, float64(number), 1e-9)
+		})
+	}
+}
+
+func TestCalc_MatrixIsNotScalar(t *testing.T) {
+	_, err := calculation.Calc("[1, 2]")
+	assert.Equal(t, calculation.ErrNotScalar, err)
+}
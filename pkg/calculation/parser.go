@@ -0,0 +1,782 @@
+package calculation
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// tokenKind тип лексемы выражения
+type tokenKind int
+
+const (
+	tokenEOF       tokenKind = iota
+	tokenNumber              // Число
+	tokenIdent               // Имя переменной, константы или функции
+	tokenOperator            // Бинарный оператор или унарный минус
+	tokenLParen              // (
+	tokenRParen              // )
+	tokenComma               // Разделитель аргументов функции или элементов строки матрицы
+	tokenLBracket            // [
+	tokenRBracket            // ]
+	tokenSemicolon           // Разделитель строк матрицы
+	tokenString              // Строка в кавычках: дата или часовой пояс
+	tokenDuration            // Длительность: 30d, 2h30m
+	tokenPercent             // Постфиксный процент
+	tokenFactorial           // Постфиксный факториал
+	tokenRoot                // Квадратный корень √
+)
+
+// token лексема выражения
+type token struct {
+	kind  tokenKind
+	text  string
+	value float64 // Значение для чисел; для длительностей - в наносекундах
+	pos   int     // Позиция начала лексемы в выражении
+}
+
+// uncertaintySigns записи знака погрешности: 3.2±0.1 или 3.2+/-0.1
+var uncertaintySigns = []string{"±", "+/-"}
+
+// elementwiseOperators записи поэлементных операций над матрицами
+var elementwiseOperators = map[string]rune{
+	".*": opElementwiseMul,
+	"./": opElementwiseDiv,
+	".^": opElementwisePow,
+}
+
+// unicodeOperators математические символы, заменяющие операторы ASCII
+var unicodeOperators = map[rune]rune{
+	'×': '*',
+	'·': '*',
+	'⋅': '*',
+	'÷': '/',
+	'−': '-',
+}
+
+// superscripts надстрочные цифры и минус: x² = x^2, 10⁻³ = 10^-3
+var superscripts = map[rune]byte{
+	'⁰': '0', '¹': '1', '²': '2', '³': '3', '⁴': '4',
+	'⁵': '5', '⁶': '6', '⁷': '7', '⁸': '8', '⁹': '9',
+	'⁻': '-',
+}
+
+// groupSeparators пробелы, разделяющие группы разрядов: 1 000 000
+var groupSeparators = map[rune]bool{
+	' ':      true,
+	'\u00a0': true, // Неразрывный пробел
+	'\u2009': true, // Тонкий пробел
+	'\u202f': true, // Узкий неразрывный пробел
+}
+
+// tokenize разбивает выражение на лексемы. Выражение разбирается по символам
+// Unicode; позиции лексем - смещения в байтах.
+func tokenize(expression string, options parseOptions) ([]token, error) {
+	tokens := make([]token, 0, len(expression)/2+1)
+	// calls - открытые скобки: true для скобки вызова функции, false для
+	// группирующей скобки и матрицы
+	var calls []bool
+
+	for i := 0; i < len(expression); {
+		currentChar, size := utf8.DecodeRuneInString(expression[i:])
+		if currentChar == utf8.RuneError && size == 1 {
+			return nil, ErrInvalidCharacter
+		}
+
+		if options.uncertainty {
+			if sign, found := matchPrefix(expression[i:], uncertaintySigns); found {
+				tokens = append(tokens, token{kind: tokenOperator, text: "±", pos: i})
+				i += len(sign)
+				continue
+			}
+		}
+
+		if i+1 < len(expression) {
+			if op, exists := elementwiseOperators[expression[i:i+2]]; exists {
+				tokens = append(tokens, token{kind: tokenOperator, text: string(op), pos: i})
+				i += 2
+				continue
+			}
+		}
+
+		if _, exists := superscripts[currentChar]; exists {
+			superscriptTokens, end, err := scanSuperscript(expression, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, superscriptTokens...)
+			i = end
+			continue
+		}
+
+		switch {
+		case unicode.IsSpace(currentChar):
+
+		case isDigit(currentChar) || currentChar == '.':
+			if options.durations && !options.isProduct(expression, i) {
+				if end, value, ok := scanDuration(expression, i); ok {
+					tokens = append(tokens, token{kind: tokenDuration, text: expression[i:end], value: value, pos: i})
+					i = end
+					continue
+				}
+			}
+			inArguments := len(calls) > 0 && calls[len(calls)-1]
+			end, text, err := scanNumber(expression, i, options, inArguments)
+			if err != nil {
+				return nil, err
+			}
+			value, err := strconv.ParseFloat(text, 64)
+			if err != nil {
+				// Грамматика уже проверена - остается только выход за пределы float64
+				return nil, newSyntaxError(expression, i, ErrInvalidNumber)
+			}
+			tokens = append(tokens, token{kind: tokenNumber, text: text, value: value, pos: i})
+			i = end
+			continue
+
+		case currentChar == 'π':
+			// π - отдельное имя: в режиме неявного умножения 2πr читается как
+			// 2*π*r, без него 2π - ошибка, как и 2x
+			tokens = append(tokens, token{kind: tokenIdent, text: "π", pos: i})
+
+		case unicode.IsLetter(currentChar) || currentChar == '_':
+			end := i
+			for end < len(expression) {
+				r, width := utf8.DecodeRuneInString(expression[end:])
+				if !isIdentifierChar(r) || r == 'π' {
+					break
+				}
+				end += width
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: expression[i:end], pos: i})
+			i = end
+			continue
+
+		case currentChar == '"' || currentChar == '\'':
+			end := strings.IndexByte(expression[i+1:], expression[i])
+			if end < 0 {
+				return nil, ErrInvalidExpression
+			}
+			tokens = append(tokens, token{kind: tokenString, text: expression[i+1 : i+1+end], pos: i})
+			i += end + 2
+			continue
+
+		case currentChar == '(':
+			calls = append(calls, len(tokens) > 0 && tokens[len(tokens)-1].kind == tokenIdent)
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: i})
+
+		case currentChar == ')':
+			calls = calls[:max(len(calls)-1, 0)]
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: i})
+
+		case currentChar == ',':
+			tokens = append(tokens, token{kind: tokenComma, text: ",", pos: i})
+
+		case currentChar == '[':
+			calls = append(calls, false)
+			tokens = append(tokens, token{kind: tokenLBracket, text: "[", pos: i})
+
+		case currentChar == ']':
+			calls = calls[:max(len(calls)-1, 0)]
+			tokens = append(tokens, token{kind: tokenRBracket, text: "]", pos: i})
+
+		case currentChar == ';':
+			tokens = append(tokens, token{kind: tokenSemicolon, text: ";", pos: i})
+
+		case currentChar == '!':
+			tokens = append(tokens, token{kind: tokenFactorial, text: "!", pos: i})
+
+		case currentChar == '%' && options.percent:
+			tokens = append(tokens, token{kind: tokenPercent, text: "%", pos: i})
+
+		case currentChar == '√':
+			tokens = append(tokens, token{kind: tokenRoot, text: "√", pos: i})
+
+		case unicodeOperators[currentChar] != 0:
+			tokens = append(tokens, token{kind: tokenOperator, text: string(unicodeOperators[currentChar]), pos: i})
+
+		case currentChar < utf8.RuneSelf && isOperator(currentChar):
+			tokens = append(tokens, token{kind: tokenOperator, text: string(currentChar), pos: i})
+
+		default:
+			return nil, ErrInvalidCharacter
+		}
+
+		i += size
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(expression)}), nil
+}
+
+// matchPrefix возвращает вариант, с которого начинается строка
+func matchPrefix(s string, variants []string) (string, bool) {
+	for _, variant := range variants {
+		if strings.HasPrefix(s, variant) {
+			return variant, true
+		}
+	}
+	return "", false
+}
+
+// isDigit проверяет, что символ - цифра ASCII
+func isDigit(ch rune) bool {
+	return '0' <= ch && ch <= '9'
+}
+
+// digitAt проверяет, что в позиции index стоит цифра ASCII
+func digitAt(expression string, index int) bool {
+	return index < len(expression) && isDigit(rune(expression[index]))
+}
+
+// scanNumber разбирает число, начинающееся с startIndex, и возвращает индекс
+// его конца и запись без разделителей групп разрядов. Грамматика числа:
+//
+//	цифры [ "." [цифры] ] [ ("e" | "E") ["+" | "-"] цифры ]  или  "." цифры [экспонента]
+//
+// Цифры можно разделять подчеркиванием (1_000_000) или пробелом по три
+// (1 000 000); при decimalComma дробную часть отделяет и запятая между цифрами
+// (3,5). Неправильная запись (1.2.3, 1e, 1e+, 1_) - ошибка ErrInvalidNumber с позицией.
+// В аргументах функции (inArguments) запятая между цифрами может быть и
+// разделителем: nCr(5,2) - ошибка ErrAmbiguousComma с позицией запятой.
+func scanNumber(expression string, startIndex int, options parseOptions, inArguments bool) (int, string, error) {
+	var text strings.Builder
+	end := startIndex
+
+	// scanDigits читает цифры, разделенные подчеркиваниями, и возвращает их количество
+	scanDigits := func() int {
+		count := 0
+		for digitAt(expression, end) || (count > 0 && end < len(expression) && expression[end] == '_' && digitAt(expression, end+1)) {
+			if expression[end] != '_' {
+				text.WriteByte(expression[end])
+				count++
+			}
+			end++
+		}
+		return count
+	}
+	malformed := func(pos int) (int, string, error) {
+		return 0, "", newSyntaxError(expression, pos, ErrInvalidNumber)
+	}
+
+	digits := scanDigits()
+	if digits > 0 && digits <= 3 {
+		for {
+			separator, width := utf8.DecodeRuneInString(expression[end:])
+			group := end + width
+			if !groupSeparators[separator] || !digitAt(expression, group) || !digitAt(expression, group+1) ||
+				!digitAt(expression, group+2) || digitAt(expression, group+3) {
+				break
+			}
+			text.WriteString(expression[group : group+3])
+			end = group + 3
+		}
+	}
+
+	// Точка перед оператором - часть поэлементной операции: [1, 2].*3
+	if end < len(expression) && expression[end] == '.' {
+		if _, elementwise := elementwiseOperators[expression[end:min(end+2, len(expression))]]; !elementwise {
+			text.WriteByte('.')
+			end++
+			digits += scanDigits()
+		}
+	} else if options.decimalComma && digits > 0 && end < len(expression) && expression[end] == ',' && digitAt(expression, end+1) {
+		if inArguments {
+			return 0, "", newSyntaxError(expression, end, ErrAmbiguousComma)
+		}
+		text.WriteByte('.')
+		end++
+		digits += scanDigits()
+	}
+	if digits == 0 {
+		return malformed(startIndex)
+	}
+
+	if end < len(expression) && (expression[end] == 'e' || expression[end] == 'E') {
+		exponent := end + 1
+		if exponent < len(expression) && (expression[exponent] == '+' || expression[exponent] == '-') {
+			exponent++
+		}
+		switch {
+		case digitAt(expression, exponent):
+			text.WriteString(expression[end:exponent])
+			end = exponent
+			scanDigits()
+		case options.implicit:
+			// При неявном умножении 2e - это 2*e
+		case exponent > end+1 || exponent >= len(expression) || !isIdentifierChar(rune(expression[exponent])):
+			// 1e, 1e+ и 1e) - экспонента без цифр; 1em оставляем разбору имен
+			return malformed(end)
+		}
+	}
+
+	// Вторая десятичная точка или подчеркивание без цифры после него
+	if end < len(expression) && (expression[end] == '_' || (expression[end] == '.' && digitAt(expression, end+1))) {
+		return malformed(end)
+	}
+
+	return end, text.String(), nil
+}
+
+// scanSuperscript разбирает надстрочную степень, начинающуюся с startIndex:
+// x² превращается в лексемы x ^ 2, 10⁻³ - в 10 ^ - 3
+func scanSuperscript(expression string, startIndex int) ([]token, int, error) {
+	tokens := []token{{kind: tokenOperator, text: "^", pos: startIndex}}
+
+	end := startIndex
+	var digits strings.Builder
+	digitsStart := startIndex
+	for end < len(expression) {
+		r, width := utf8.DecodeRuneInString(expression[end:])
+		ch, exists := superscripts[r]
+		if !exists {
+			break
+		}
+		if ch == '-' {
+			if digits.Len() > 0 || end != startIndex {
+				return nil, 0, ErrInvalidExpression
+			}
+			tokens = append(tokens, token{kind: tokenOperator, text: "-", pos: end})
+			digitsStart = end + width
+		} else {
+			digits.WriteByte(ch)
+		}
+		end += width
+	}
+
+	if digits.Len() == 0 {
+		return nil, 0, ErrInvalidExpression
+	}
+	value, _ := strconv.ParseFloat(digits.String(), 64)
+	tokens = append(tokens, token{kind: tokenNumber, text: digits.String(), value: value, pos: digitsStart})
+	return tokens, end, nil
+}
+
+// durationUnits суффиксы длительностей; ms проверяется раньше m
+var durationUnits = []struct {
+	suffix string
+	unit   time.Duration
+}{
+	{"ms", time.Millisecond},
+	{"w", 7 * 24 * time.Hour},
+	{"d", 24 * time.Hour},
+	{"h", time.Hour},
+	{"m", time.Minute},
+	{"s", time.Second},
+}
+
+// scanDuration разбирает длительность, начинающуюся с startIndex: одно или
+// несколько чисел с суффиксами без пробелов (30d, 2h30m, 1.5h). Возвращает
+// индекс конца и значение в наносекундах
+func scanDuration(expression string, startIndex int) (int, float64, bool) {
+	pos := startIndex
+	total := 0.0
+
+	for pos < len(expression) && (digitAt(expression, pos) || expression[pos] == '.') {
+		end := pos
+		for end < len(expression) && (digitAt(expression, end) || expression[end] == '.') {
+			end++
+		}
+		amount, err := strconv.ParseFloat(expression[pos:end], 64)
+		if err != nil {
+			return 0, 0, false
+		}
+
+		matched := false
+		for _, unit := range durationUnits {
+			if strings.HasPrefix(expression[end:], unit.suffix) {
+				total += amount * float64(unit.unit)
+				pos = end + len(unit.suffix)
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return 0, 0, false
+		}
+	}
+
+	// Суффикс должен завершать лексему: 3min - не длительность
+	if next, _ := utf8.DecodeRuneInString(expression[pos:]); pos < len(expression) && isIdentifierChar(next) {
+		return 0, 0, false
+	}
+	if total >= math.MaxInt64 {
+		return 0, 0, false
+	}
+	return pos, total, true
+}
+
+// node узел синтаксического дерева выражения
+type node interface{}
+
+// numberNode числовая константа
+type numberNode struct {
+	value float64
+	text  string // Запись числа в выражении; нужна для точных границ в режиме интервалов
+}
+
+// stringNode строковый литерал; допустим только как аргумент функций дат
+type stringNode struct {
+	value string
+}
+
+// durationNode длительность
+type durationNode struct {
+	value time.Duration
+}
+
+// variableNode переменная или именованная константа
+type variableNode struct {
+	name string
+}
+
+// unaryNode унарный минус
+type unaryNode struct {
+	op      rune
+	operand node
+}
+
+// binaryNode бинарная операция
+type binaryNode struct {
+	op          rune
+	left, right node
+}
+
+// matrixNode матрица [1, 2; 3, 4]: строки разделяются ';', элементы - ','
+type matrixNode struct {
+	rows [][]node
+}
+
+// percentNode процент b%; существует только во время разбора: в a + b% и a - b%
+// процент берется от a, в остальных случаях b% = b/100
+type percentNode struct {
+	operand node
+}
+
+// callNode вызов функции; аргументы вычисляются самой функцией,
+// поэтому специальные формы (integrate, sum, prod) получают их невычисленными
+type callNode struct {
+	name string
+	args []node
+}
+
+// unaryPrecedence приоритет унарного минуса: ниже степени, поэтому -2^2 = -(2^2)
+const unaryPrecedence = 3
+
+// Разбор ведется в силе связывания - удвоенном приоритете, чтобы неявное
+// умножение встало между * и ^: 1/2x = 1/(2x), x^2y = (x^2)*y
+const (
+	lowestPower   = 2 // Сила связывания сложения - всё выражение
+	implicitPower = 5 // Сила связывания неявного умножения
+)
+
+// bindingPower возвращает силу связывания операции с заданным приоритетом
+func bindingPower(precedence int) int {
+	return 2 * precedence
+}
+
+// parseOptions настройки разбора выражения
+type parseOptions struct {
+	quantities  bool // Число, за которым следует имя, - величина с единицей измерения: 5 km, 3 m^2
+	uncertainty bool // Разрешен оператор погрешности: 3.2±0.1
+	durations   bool // Число с суффиксом времени - длительность: 30d, 2h30m
+	percent     bool // Постфиксный процент: 200 + 15% = 230
+	implicit    bool // Неявное умножение: 2x, 3pi, 2(3+4), (1+2)(3+4)
+	// Определенные переменные: при неявном умножении 2m - произведение 2*m,
+	// а не длительность, если переменная m задана
+	variables map[string]float64
+	// Запятая между цифрами - десятичный разделитель: 3,5. В аргументах
+	// функций такая запятая неоднозначна и запрещена: max(1,5, 2)
+	decimalComma bool
+}
+
+// isProduct проверяет, что число в позиции start с именем за ним - неявное
+// произведение на определенную переменную
+func (options parseOptions) isProduct(expression string, start int) bool {
+	if !options.implicit {
+		return false
+	}
+	nameStart := start
+	for nameStart < len(expression) && (digitAt(expression, nameStart) || expression[nameStart] == '.') {
+		nameStart++
+	}
+	nameEnd := nameStart
+	for nameEnd < len(expression) {
+		r, size := utf8.DecodeRuneInString(expression[nameEnd:])
+		if !isIdentifierChar(r) {
+			break
+		}
+		nameEnd += size
+	}
+	_, exists := options.variables[expression[nameStart:nameEnd]]
+	return exists
+}
+
+// parser строит синтаксическое дерево методом подъема по приоритетам
+type parser struct {
+	tokens  []token
+	pos     int
+	options parseOptions
+}
+
+// parse разбирает выражение в синтаксическое дерево
+func parse(expression string, options parseOptions) (node, error) {
+	tokens, err := tokenize(expression, options)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens, options: options}
+	root, err := p.parseExpression(lowestPower)
+	if err != nil {
+		return nil, err
+	}
+
+	switch p.peek().kind {
+	case tokenEOF:
+		return lowerPercent(root), nil
+	case tokenRParen:
+		return nil, ErrMismatchedParens
+	default:
+		return nil, ErrInvalidExpression
+	}
+}
+
+// peek возвращает текущую лексему
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+// next возвращает текущую лексему и переходит к следующей
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// parseExpression разбирает бинарные операции с силой связывания не ниже minPower
+func (p *parser) parseExpression(minPower int) (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		tok := p.peek()
+
+		// Имя или скобка сразу после операнда - неявное умножение
+		if p.options.implicit && (tok.kind == tokenIdent || tok.kind == tokenLParen || tok.kind == tokenRoot) {
+			if implicitPower < minPower {
+				return left, nil
+			}
+			right, err := p.parseExpression(implicitPower + 1)
+			if err != nil {
+				return nil, err
+			}
+			left = binaryOperation('*', left, right)
+			continue
+		}
+
+		if tok.kind != tokenOperator {
+			return left, nil
+		}
+
+		op, _ := utf8.DecodeRuneInString(tok.text)
+		current := operators[op]
+		power := bindingPower(current.precedence)
+		if power < minPower {
+			return left, nil
+		}
+		p.next()
+
+		nextPower := power + 1
+		if current.rightAssociative {
+			nextPower = power
+		}
+
+		right, err := p.parseExpression(nextPower)
+		if err != nil {
+			return nil, err
+		}
+		left = binaryOperation(op, left, right)
+	}
+}
+
+// parseUnary разбирает унарный минус и постфиксные операции: процент и факториал.
+// Постфиксные операции связывают сильнее степени: 2^3! = 2^(3!), -3! = -(3!)
+func (p *parser) parseUnary() (node, error) {
+	if tok := p.peek(); tok.kind == tokenOperator && tok.text == "-" {
+		p.next()
+		operand, err := p.parseExpression(bindingPower(unaryPrecedence))
+		if err != nil {
+			return nil, err
+		}
+		return unaryNode{op: '-', operand: lowerPercent(operand)}, nil
+	}
+
+	// √x = sqrt(x); как и унарный минус, корень распространяется на степень: √x² = √(x²)
+	if p.peek().kind == tokenRoot {
+		p.next()
+		operand, err := p.parseExpression(bindingPower(unaryPrecedence))
+		if err != nil {
+			return nil, err
+		}
+		return callNode{name: "sqrt", args: []node{lowerPercent(operand)}}, nil
+	}
+
+	primary, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch p.peek().kind {
+		case tokenPercent:
+			primary = percentNode{operand: lowerPercent(primary)}
+		case tokenFactorial:
+			primary = callNode{name: "factorial", args: []node{lowerPercent(primary)}}
+		default:
+			return primary, nil
+		}
+		p.next()
+	}
+}
+
+// binaryOperation строит бинарную операцию; a ± b% превращается в операцию
+// a ± a*b/100, в которой a вычисляется один раз
+func binaryOperation(op rune, left, right node) node {
+	if percent, ok := right.(percentNode); ok && (op == '+' || op == '-') {
+		if op == '+' {
+			op = opPercentAdd
+		} else {
+			op = opPercentSub
+		}
+		return binaryNode{op: op, left: lowerPercent(left), right: percent.operand}
+	}
+	return binaryNode{op: op, left: lowerPercent(left), right: lowerPercent(right)}
+}
+
+// lowerPercent заменяет процент b% делением b/100
+func lowerPercent(n node) node {
+	if percent, ok := n.(percentNode); ok {
+		return binaryNode{op: '/', left: percent.operand, right: numberNode{value: 100, text: "100"}}
+	}
+	return n
+}
+
+// parsePrimary разбирает число, переменную, вызов функции или выражение в скобках
+func (p *parser) parsePrimary() (node, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokenNumber:
+		number := numberNode{value: tok.value, text: tok.text}
+		if p.options.quantities && p.peek().kind == tokenIdent {
+			unit, err := p.parseExpression(bindingPower(unaryPrecedence))
+			if err != nil {
+				return nil, err
+			}
+			return binaryNode{op: '*', left: number, right: lowerPercent(unit)}, nil
+		}
+		return number, nil
+
+	case tokenDuration:
+		return durationNode{value: time.Duration(tok.value)}, nil
+
+	case tokenString:
+		return stringNode{value: tok.text}, nil
+
+	case tokenIdent:
+		if p.peek().kind != tokenLParen {
+			return variableNode{name: tok.text}, nil
+		}
+		p.next()
+		args, err := p.parseArguments()
+		if err != nil {
+			return nil, err
+		}
+		return callNode{name: tok.text, args: args}, nil
+
+	case tokenLParen:
+		inner, err := p.parseExpression(lowestPower)
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expectClosingParenthesis(); err != nil {
+			return nil, err
+		}
+		return lowerPercent(inner), nil
+
+	case tokenLBracket:
+		return p.parseMatrix()
+
+	default:
+		return nil, ErrInvalidExpression
+	}
+}
+
+// parseArguments разбирает аргументы функции после открывающей скобки
+func (p *parser) parseArguments() ([]node, error) {
+	var args []node
+	if p.peek().kind == tokenRParen {
+		p.next()
+		return args, nil
+	}
+
+	for {
+		arg, err := p.parseExpression(lowestPower)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, lowerPercent(arg))
+
+		if p.peek().kind != tokenComma {
+			break
+		}
+		p.next()
+	}
+
+	return args, p.expectClosingParenthesis()
+}
+
+// parseMatrix разбирает элементы матрицы после открывающей квадратной скобки
+func (p *parser) parseMatrix() (node, error) {
+	matrix := matrixNode{rows: [][]node{nil}}
+
+	for {
+		element, err := p.parseExpression(lowestPower)
+		if err != nil {
+			return nil, err
+		}
+		last := len(matrix.rows) - 1
+		matrix.rows[last] = append(matrix.rows[last], lowerPercent(element))
+
+		switch p.next().kind {
+		case tokenComma:
+		case tokenSemicolon:
+			matrix.rows = append(matrix.rows, nil)
+		case tokenRBracket:
+			return matrix, nil
+		case tokenEOF:
+			return nil, ErrMismatchedParens
+		default:
+			return nil, ErrInvalidExpression
+		}
+	}
+}
+
+// expectClosingParenthesis проверяет, что текущая лексема - закрывающая скобка
+func (p *parser) expectClosingParenthesis() error {
+	switch p.peek().kind {
+	case tokenRParen:
+		p.next()
+		return nil
+	case tokenEOF:
+		return ErrMismatchedParens
+	default:
+		return ErrInvalidExpression
+	}
+}
@@ -0,0 +1,93 @@
+package calculation_test
+
+import (
+	"testing"
+
+	"github.com/flexer2006/y.lms_sprint1_Calc/pkg/calculation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalc_Percent(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    float64
+		expectedErr error
+	}{
+		{"markup", "200 + 15%", 230, nil},
+		{"discount", "200 - 15%", 170, nil},
+		{"percent of number", "50% * 80", 40, nil},
+		{"number times percent", "80 * 50%", 40, nil},
+		{"division by percent", "30 / 15%", 200, nil},
+		{"standalone percent", "15%", 0.15, nil},
+		{"chained markups", "200 + 10% + 10%", 242, nil},
+		{"markup of expression", "(100 + 100) + 15%", 230, nil},
+		{"percent of product", "2 * 100 + 15%", 230, nil},
+		{"percent inside product", "200 + 15% * 2", 200.3, nil},
+		{"parenthesized percent", "200 + (15%)", 200.15, nil},
+		{"percent of variable", "price + 20%", 120, nil},
+		{"percent in function", "sqrt(4%)", 0.2, nil},
+		{"markup of fraction", "0.1 + 10%", 0.11, nil},
+		{"markup of duration", "hours(2h + 10%)", 2.2, nil},
+		{"missing operand", "%", 0, calculation.ErrInvalidExpression},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calc := calculation.NewCalculator()
+			calc.SetOptions(calculation.Options{Percent: true})
+			calc.SetVariable("price", 100)
+
+			result, err := calc.Calc(tt.input)
+			if tt.expectedErr != nil {
+				assert.Equal(t, tt.expectedErr, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestCalc_PercentDisabled(t *testing.T) {
+	_, err := calculation.Calc("200 + 15%")
+	assert.Equal(t, calculation.ErrInvalidCharacter, err)
+}
+
+func TestCalcUncertain_Percent(t *testing.T) {
+	calc := calculation.NewCalculator()
+	calc.SetOptions(calculation.Options{Percent: true})
+
+	result, err := calc.CalcUncertain("(100 ± 2) + 10%")
+	assert.NoError(t, err)
+	assert.Equal(t, 110.0, result.Value)
+	assert.Equal(t, 2.2, result.Uncertainty)
+}
+
+func TestCalc_PercentModes(t *testing.T) {
+	calc := calculation.NewCalculator()
+	calc.SetOptions(calculation.Options{Percent: true})
+
+	integer, err := calc.CalcInteger("200 + 15%")
+	assert.NoError(t, err)
+	assert.Equal(t, "230", integer.String())
+
+	quantity, err := calc.CalcQuantity("5 km - 10%")
+	assert.NoError(t, err)
+	assert.Equal(t, 4.5, quantity.Value)
+	assert.Equal(t, "km", quantity.Unit)
+
+	interval, err := calc.CalcInterval("200 + 15%")
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, interval.Lower, 230.0)
+	assert.GreaterOrEqual(t, interval.Upper, 230.0)
+
+	plan, err := calc.Plan("200 + 15%")
+	assert.NoError(t, err)
+	tasks := plan.Ready()
+	assert.Len(t, tasks, 1)
+	result, err := calculation.ComputeTask(tasks[0])
+	assert.NoError(t, err)
+	assert.Equal(t, "+%", tasks[0].Operation)
+	assert.Equal(t, 230.0, result)
+}
@@ -0,0 +1,285 @@
+package calculation
+
+import "errors"
+
+// Task операция плана, операнды которой уже известны: бинарная операция
+// ("+", "-", "*", "/", "^") или функция ("sin", "max", ...)
+type Task struct {
+	ID        int       // Номер операции в плане
+	Operation string    // Оператор или имя функции
+	Args      []float64 // Значения операндов
+}
+
+// Plan разбиение выражения на операции, которые можно вычислять независимо
+// и параллельно: в (1+2)*(3+4) сложения не зависят друг от друга. План не
+// безопасен для одновременного использования.
+type Plan struct {
+	calc     *Calculator // Настройки калькулятора: политика особых значений
+	steps    []planStep  // Операции; корень выражения - последняя
+	value    float64     // Значение выражения без операций: "-5", "pi"
+	finished int         // Количество вычисленных операций
+}
+
+// planStep операция плана
+type planStep struct {
+	operation string
+	operands  []planOperand
+	parent    int // Операция, использующая результат; -1 для корня
+	slot      int // Номер операнда в родительской операции
+	pending   int // Количество еще не вычисленных операндов
+	issued    bool
+	done      bool
+}
+
+// planOperand операнд: число или результат операции step
+type planOperand struct {
+	step  int // -1 для числа
+	value float64
+}
+
+// Plan разбирает выражение на операции с учетом переменных и настроек калькулятора.
+// Разбиваются числа, переменные, операторы и функции чисел; выражения с матрицами,
+// датами, строками и специальными формами возвращают ErrNotDistributable.
+func (c *Calculator) Plan(expression string) (*Plan, error) {
+	root, err := c.parse(expression, parseOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Plan{calc: c}
+	operand, err := p.add(root)
+	if err != nil {
+		return nil, err
+	}
+	if operand.step < 0 {
+		p.value = operand.value
+	} else {
+		p.steps[operand.step].parent = -1
+	}
+	return p, nil
+}
+
+// planOperations операторы плана; поэлементные над числами совпадают с обычными,
+// наценка и скидка a ± b% передаются агентам как "+%" и "-%"
+var planOperations = map[rune]string{
+	'+': "+", '-': "-", '*': "*", '/': "/", '^': "^",
+	opElementwiseMul: "*", opElementwiseDiv: "/", opElementwisePow: "^",
+	opPercentAdd: "+%", opPercentSub: "-%",
+}
+
+// percentTasks внутренние операторы наценки и скидки по названию операции плана
+var percentTasks = map[string]rune{"+%": opPercentAdd, "-%": opPercentSub}
+
+// add добавляет в план операции узла и возвращает операнд с его значением
+func (p *Plan) add(n node) (planOperand, error) {
+	switch n := n.(type) {
+	case numberNode:
+		return planOperand{step: -1, value: n.value}, nil
+
+	case variableNode:
+		if value, exists := p.calc.variables[n.name]; exists {
+			return planOperand{step: -1, value: value}, nil
+		}
+		if value, exists := constants[n.name]; exists {
+			return planOperand{step: -1, value: value}, nil
+		}
+		return planOperand{}, ErrUnknownVariable
+
+	case unaryNode:
+		operand, err := p.add(n.operand)
+		if err != nil {
+			return planOperand{}, err
+		}
+		if operand.step < 0 {
+			return planOperand{step: -1, value: -operand.value}, nil
+		}
+		return p.addStep("*", []planOperand{{step: -1, value: -1}, operand}), nil
+
+	case binaryNode:
+		operation, exists := planOperations[n.op]
+		if !exists {
+			return planOperand{}, ErrNotDistributable
+		}
+		left, err := p.add(n.left)
+		if err != nil {
+			return planOperand{}, err
+		}
+		right, err := p.add(n.right)
+		if err != nil {
+			return planOperand{}, err
+		}
+		return p.addStep(operation, []planOperand{left, right}), nil
+
+	case callNode:
+		if err := p.calc.checkPlanFunction(n); err != nil {
+			return planOperand{}, err
+		}
+		operands := make([]planOperand, len(n.args))
+		for i, arg := range n.args {
+			operand, err := p.add(arg)
+			if err != nil {
+				return planOperand{}, err
+			}
+			operands[i] = operand
+		}
+		return p.addStep(n.name, operands), nil
+
+	default:
+		return planOperand{}, ErrNotDistributable
+	}
+}
+
+// checkPlanFunction проверяет, что функция вычисляется над числами
+func (c *Calculator) checkPlanFunction(n callNode) error {
+	if _, exists := specialForms[n.name]; exists && c.isSpecialFormCall(n) {
+		return ErrNotDistributable
+	}
+	if _, exists := temporalFunctions[n.name]; exists {
+		return ErrNotDistributable
+	}
+	if _, exists := matrixFunctions[n.name]; exists {
+		return ErrNotDistributable
+	}
+	if fn, exists := aggregates[n.name]; exists {
+		if len(n.args) <= fn.params {
+			return ErrArgumentCount
+		}
+		return nil
+	}
+
+	fn, exists := functions[n.name]
+	if !exists {
+		return ErrUnknownFunction
+	}
+	if len(n.args) < fn.minArgs || (fn.maxArgs >= 0 && len(n.args) > fn.maxArgs) {
+		return ErrArgumentCount
+	}
+	return nil
+}
+
+// addStep добавляет операцию и связывает с ней операнды-операции
+func (p *Plan) addStep(operation string, operands []planOperand) planOperand {
+	step := len(p.steps)
+	pending := 0
+	for slot, operand := range operands {
+		if operand.step >= 0 {
+			p.steps[operand.step].parent = step
+			p.steps[operand.step].slot = slot
+			pending++
+		}
+	}
+	p.steps = append(p.steps, planStep{operation: operation, operands: operands, pending: pending})
+	return planOperand{step: step}
+}
+
+// Ready возвращает операции, все операнды которых известны, и отмечает их выданными
+func (p *Plan) Ready() []Task {
+	var tasks []Task
+	for i := range p.steps {
+		step := &p.steps[i]
+		if step.issued || step.pending > 0 {
+			continue
+		}
+		step.issued = true
+
+		args := make([]float64, len(step.operands))
+		for j, operand := range step.operands {
+			args[j] = operand.value
+		}
+		tasks = append(tasks, Task{ID: i, Operation: step.operation, Args: args})
+	}
+	return tasks
+}
+
+// Complete записывает результат операции и применяет к нему политику особых значений
+func (p *Plan) Complete(id int, result float64) error {
+	if id < 0 || id >= len(p.steps) || !p.steps[id].issued || p.steps[id].done {
+		return ErrUnknownTask
+	}
+	step := &p.steps[id]
+
+	lost := false
+	if op := []rune(step.operation); len(step.operands) == 2 && len(op) == 1 {
+		lost = underflowed(op[0], Number(step.operands[0].value), Number(step.operands[1].value), Number(result))
+	}
+	result, err := p.calc.checkSpecialValue(result, lost)
+	if err != nil {
+		return err
+	}
+
+	step.done = true
+	p.finished++
+	if step.parent >= 0 {
+		parent := &p.steps[step.parent]
+		parent.operands[step.slot].value = result
+		parent.pending--
+	} else {
+		p.value = result
+	}
+	return nil
+}
+
+// Result возвращает значение выражения, если все операции вычислены
+func (p *Plan) Result() (float64, bool) {
+	return p.value, p.finished == len(p.steps)
+}
+
+// Progress возвращает количество вычисленных операций и их общее количество
+func (p *Plan) Progress() (int, int) {
+	return p.finished, len(p.steps)
+}
+
+// ComputeTask вычисляет операцию плана. Результат не проверяется политикой
+// особых значений: это делает Plan.Complete по настройкам выражения.
+func ComputeTask(task Task) (float64, error) {
+	op := []rune(task.Operation)
+	if percent, exists := percentTasks[task.Operation]; exists {
+		op = []rune{percent}
+	}
+	if len(op) == 1 {
+		if operator, exists := operators[op[0]]; exists && op[0] != '±' {
+			if len(task.Args) != 2 {
+				return 0, ErrArgumentCount
+			}
+			return operator.operation(task.Args[0], task.Args[1])
+		}
+	}
+
+	if fn, exists := aggregates[task.Operation]; exists {
+		args := make([]Value, len(task.Args))
+		for i, arg := range task.Args {
+			args[i] = Number(arg)
+		}
+		result, err := fn.apply(args)
+		if err != nil {
+			return 0, err
+		}
+		return float64(result.(Number)), nil
+	}
+
+	fn, exists := functions[task.Operation]
+	if !exists {
+		return 0, ErrUnknownFunction
+	}
+	if len(task.Args) < fn.minArgs || (fn.maxArgs >= 0 && len(task.Args) > fn.maxArgs) {
+		return 0, ErrArgumentCount
+	}
+	return fn.call(task.Args)
+}
+
+// TaskError возвращает ошибку вычисления по ее тексту, чтобы ошибку операции,
+// полученную по сети, можно было сравнить с ErrDivisionByZero и другими
+func TaskError(message string) error {
+	for _, err := range taskErrors {
+		if err.Error() == message {
+			return err
+		}
+	}
+	return errors.New(message)
+}
+
+// taskErrors ошибки, которые может вернуть ComputeTask или Plan.Complete
+var taskErrors = []error{
+	ErrDivisionByZero, ErrInvalidArgument, ErrArgumentCount, ErrUnknownFunction,
+	ErrOverflow, ErrUnderflow, ErrNaN, ErrNotScalar,
+}
@@ -0,0 +1,119 @@
+package calculation_test
+
+import (
+	"testing"
+
+	"github.com/flexer2006/y.lms_sprint1_Calc/pkg/calculation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runPlan вычисляет план так, как это делают агенты: волнами готовых операций
+func runPlan(t *testing.T, plan *calculation.Plan) (float64, int, error) {
+	waves := 0
+	for {
+		if result, done := plan.Result(); done {
+			return result, waves, nil
+		}
+		tasks := plan.Ready()
+		require.NotEmpty(t, tasks)
+		waves++
+		for _, task := range tasks {
+			result, err := calculation.ComputeTask(task)
+			if err != nil {
+				return 0, waves, err
+			}
+			if err := plan.Complete(task.ID, result); err != nil {
+				return 0, waves, err
+			}
+		}
+	}
+}
+
+func TestPlan(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		expectedWaves int
+	}{
+		{"number", "42", 0},
+		{"negative constant", "-pi", 0},
+		{"single operation", "2 + 3", 1},
+		{"independent subtrees", "(1 + 2) * (3 + 4)", 2},
+		{"chain", "1 + 2 + 3 + 4", 3},
+		{"precedence", "2 + 3 * 4 - 10 / 5", 3},
+		{"power", "2^3^2", 2},
+		{"unary minus", "-(2 + 3) * 4", 3},
+		{"functions", "sqrt(16) + max(1, 7, 3) * sin(0)", 3},
+		{"variables", "x * y + 1", 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calc := calculation.NewCalculator()
+			calc.SetVariable("x", 3)
+			calc.SetVariable("y", 4)
+
+			expected, err := calc.Calc(tt.input)
+			require.NoError(t, err)
+
+			plan, err := calc.Plan(tt.input)
+			require.NoError(t, err)
+			result, waves, err := runPlan(t, plan)
+			require.NoError(t, err)
+			assert.InDelta(t, expected, result, 1e-12)
+			assert.Equal(t, tt.expectedWaves, waves)
+
+			done, total := plan.Progress()
+			assert.Equal(t, done, total)
+		})
+	}
+}
+
+func TestPlan_Errors(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expectedErr error
+	}{
+		{"matrix", "[1, 2] * 3", calculation.ErrNotDistributable},
+		{"date", `date("2026-10-17")`, calculation.ErrNotDistributable},
+		{"special form", "integrate(x^2, x, 0, 1)", calculation.ErrNotDistributable},
+		{"unknown variable", "z + 1", calculation.ErrUnknownVariable},
+		{"unknown function", "foo(1)", calculation.ErrUnknownFunction},
+		{"argument count", "sqrt(1, 2)", calculation.ErrArgumentCount},
+		{"syntax", "2 +", calculation.ErrInvalidExpression},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := calculation.NewCalculator().Plan(tt.input)
+			assert.ErrorIs(t, err, tt.expectedErr)
+		})
+	}
+}
+
+func TestPlan_TaskErrors(t *testing.T) {
+	plan, err := calculation.NewCalculator().Plan("1 / (2 - 2)")
+	require.NoError(t, err)
+	_, _, err = runPlan(t, plan)
+	assert.Equal(t, calculation.ErrDivisionByZero, err)
+
+	plan, err = calculation.NewCalculator().Plan("1e308 * 10")
+	require.NoError(t, err)
+	_, _, err = runPlan(t, plan)
+	assert.Equal(t, calculation.ErrOverflow, err)
+
+	plan, err = calculation.NewCalculator().Plan("1 + 2")
+	require.NoError(t, err)
+	assert.Equal(t, calculation.ErrUnknownTask, plan.Complete(0, 3), "task was not issued")
+	tasks := plan.Ready()
+	require.Len(t, tasks, 1)
+	require.NoError(t, plan.Complete(tasks[0].ID, 3))
+	assert.Equal(t, calculation.ErrUnknownTask, plan.Complete(tasks[0].ID, 3), "task is already done")
+}
+
+func TestTaskError(t *testing.T) {
+	assert.Equal(t, calculation.ErrDivisionByZero, calculation.TaskError(calculation.ErrDivisionByZero.Error()))
+	assert.EqualError(t, calculation.TaskError("agent crashed"), "agent crashed")
+}
@@ -0,0 +1,463 @@
+package calculation
+
+import (
+	"errors"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+)
+
+const (
+	solveMaxIterations  = 100    // Максимальное число итераций одного метода
+	solveTolerance      = 1e-12  // Требуемая относительная точность по переменной
+	solveResidualFactor = 1e-9   // Допустимая невязка относительно значений функции на концах отрезка
+	solveScanSegments   = 1000   // Количество отрезков, на которые делится область поиска
+	solveDefaultRange   = 1000.0 // Область поиска [-solveDefaultRange, solveDefaultRange] по умолчанию
+	solveExpandSteps    = 60     // Количество расширений отрезка вокруг начального приближения
+	solveDerivativeStep = 1e-7   // Относительный шаг численной производной
+)
+
+// Методы, которыми может быть найден корень
+const (
+	MethodBrent  = "brent"
+	MethodNewton = "newton"
+)
+
+// SolveParams задает начальные условия поиска корней
+type SolveParams struct {
+	Bracket *[2]float64 // Отрезок поиска [a, b]
+	Guess   *float64    // Начальное приближение для метода Ньютона
+}
+
+// Root описывает найденный корень и диагностику сходимости
+type Root struct {
+	Value      float64 // Значение переменной
+	Residual   float64 // Невязка |lhs - rhs| в найденной точке
+	Iterations int     // Количество итераций метода
+	Method     string  // Метод, которым найден корень
+	Converged  bool    // Достигнута ли требуемая точность
+}
+
+// SolveResult хранит найденные корни уравнения
+type SolveResult struct {
+	Roots       []Root // Корни в порядке возрастания
+	Evaluations int    // Количество вычислений левой и правой частей
+}
+
+// equation представляет уравнение lhs = rhs как функцию f(x) = lhs - rhs
+type equation struct {
+	lhs, rhs    node
+	calc        *Calculator
+	variable    string
+	evaluations int
+}
+
+// eval вычисляет f(x); деление на ноль дает NaN, чтобы поиск мог обойти полюс.
+// Переполнение и потеря значимости в точках поиска не являются ошибкой.
+func (e *equation) eval(x float64) (float64, error) {
+	e.evaluations++
+	e.calc.SetVariable(e.variable, x)
+
+	left, err := e.calc.eval(e.lhs)
+	if err == nil {
+		var right float64
+		right, err = e.calc.eval(e.rhs)
+		if err == nil {
+			return left - right, nil
+		}
+	}
+	if errors.Is(err, ErrDivisionByZero) {
+		return math.NaN(), nil
+	}
+	return 0, err
+}
+
+// SolveEquation решает уравнение вида "lhs = rhs" относительно переменной
+func SolveEquation(equation, variable string, params SolveParams) (*SolveResult, error) {
+	sides := strings.Split(equation, "=")
+	if len(sides) != 2 {
+		return nil, ErrInvalidEquation
+	}
+	return Solve(sides[0], sides[1], variable, params)
+}
+
+// Solve находит корни уравнения lhs = rhs относительно переменной.
+// Если задано начальное приближение, используется метод Ньютона с переходом
+// к методу Брента при расходимости. Иначе область поиска (заданный отрезок или
+// [-1000, 1000]) делится на части, и на каждой смене знака работает метод Брента.
+func Solve(lhs, rhs, variable string, params SolveParams) (*SolveResult, error) {
+	if strings.TrimSpace(lhs) == "" || strings.TrimSpace(rhs) == "" {
+		return nil, ErrInvalidEquation
+	}
+	if variable == "" {
+		variable = "x"
+	}
+
+	left, err := parse(lhs, parseOptions{})
+	if err != nil {
+		return nil, err
+	}
+	right, err := parse(rhs, parseOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	// Особые значения в точках поиска допустимы; формы в уравнении ограничены
+	// числом вычислений каждая по отдельности
+	calc := NewCalculator()
+	calc.SetOptions(Options{SpecialValues: SpecialValuesAllow})
+	eq := &equation{lhs: left, rhs: right, calc: calc, variable: variable}
+
+	var roots []Root
+	if params.Guess != nil {
+		roots, err = eq.solveFromGuess(*params.Guess, params.Bracket)
+	} else {
+		a, b := -solveDefaultRange, solveDefaultRange
+		if params.Bracket != nil {
+			a, b = params.Bracket[0], params.Bracket[1]
+		}
+		roots, err = eq.solveInRange(a, b)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if len(roots) == 0 {
+		return nil, ErrNoRoot
+	}
+
+	return &SolveResult{Roots: roots, Evaluations: eq.evaluations}, nil
+}
+
+// solveFromGuess ищет корень методом Ньютона, а при неудаче - методом Брента
+// на отрезке, расширяемом вокруг начального приближения
+func (e *equation) solveFromGuess(guess float64, bracket *[2]float64) ([]Root, error) {
+	if math.IsNaN(guess) || math.IsInf(guess, 0) {
+		return nil, ErrInvalidBracket
+	}
+
+	root, err := e.newton(guess)
+	if err != nil {
+		return nil, err
+	}
+	if root.Converged && (bracket == nil || (root.Value >= bracket[0] && root.Value <= bracket[1])) {
+		return []Root{root}, nil
+	}
+
+	if bracket != nil {
+		return e.solveInRange(bracket[0], bracket[1])
+	}
+
+	step := 0.1 * math.Max(1, math.Abs(guess))
+	for i := 0; i < solveExpandSteps; i++ {
+		a, b := guess-step, guess+step
+		fa, err := e.eval(a)
+		if err != nil {
+			return nil, err
+		}
+		fb, err := e.eval(b)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range []point{{a, fa}, {b, fb}} {
+			if p.f != 0 {
+				continue
+			}
+			isolated, err := e.isolatedZero(p.x)
+			if err != nil {
+				return nil, err
+			}
+			if isolated {
+				return []Root{{Value: p.x, Method: MethodBrent, Converged: true}}, nil
+			}
+		}
+		if oppositeSigns(fa, fb) {
+			root, err := e.brent(a, b, fa, fb)
+			if err != nil {
+				return nil, err
+			}
+			if root.Converged {
+				return []Root{root}, nil
+			}
+		}
+		step *= 2
+	}
+
+	return nil, nil
+}
+
+// solveInRange находит все корни на отрезке [a, b], на которых функция меняет знак.
+// Если смен знака нет, пробует метод Ньютона из середины отрезка (кратные корни).
+func (e *equation) solveInRange(a, b float64) ([]Root, error) {
+	if math.IsNaN(a) || math.IsNaN(b) || math.IsInf(a, 0) || math.IsInf(b, 0) || a >= b {
+		return nil, ErrInvalidBracket
+	}
+
+	step := (b - a) / solveScanSegments
+	grid := make([]point, solveScanSegments+1)
+	identity := true
+	for i := range grid {
+		x := a + float64(i)*step
+		if i == solveScanSegments {
+			x = b
+		}
+		f, err := e.eval(x)
+		if err != nil {
+			return nil, err
+		}
+		grid[i] = point{x: x, f: f}
+		identity = identity && (f == 0 || math.IsNaN(f))
+	}
+	if identity {
+		holds, err := e.holdsEverywhere(a, b)
+		if err != nil {
+			return nil, err
+		}
+		if holds {
+			return nil, ErrIdentity
+		}
+	}
+
+	// Ноль в узле сетки - корень, только если рядом с узлом функция не равна
+	// нулю: иначе это участок потери значимости (exp(x) при x < -745). Знаки
+	// соседних отрезков сравниваются по этим ближайшим ненулевым точкам.
+	var roots []Root
+	left, right := slices.Clone(grid), slices.Clone(grid)
+	for i, p := range grid {
+		if p.f != 0 {
+			continue
+		}
+		var err error
+		left[i], right[i], err = e.probeZero(p.x, step/4)
+		if err != nil {
+			return nil, err
+		}
+		if left[i].nonzero() || right[i].nonzero() {
+			roots = appendRoot(roots, Root{Value: p.x, Method: MethodBrent, Converged: true})
+		}
+	}
+
+	for i := 1; i < len(grid); i++ {
+		lo, hi := right[i-1], left[i]
+		if !oppositeSigns(lo.f, hi.f) {
+			continue
+		}
+		root, err := e.brent(lo.x, hi.x, lo.f, hi.f)
+		if err != nil {
+			return nil, err
+		}
+		if root.Converged {
+			roots = appendRoot(roots, root)
+		}
+	}
+
+	if len(roots) == 0 {
+		root, err := e.newton((a + b) / 2)
+		if err != nil {
+			return nil, err
+		}
+		if root.Converged && root.Value >= a && root.Value <= b {
+			roots = append(roots, root)
+		}
+	}
+
+	sort.Slice(roots, func(i, j int) bool { return roots[i].Value < roots[j].Value })
+	return roots, nil
+}
+
+// point значение функции в точке
+type point struct {
+	x, f float64
+}
+
+// nonzero проверяет, что знак функции в точке определен
+func (p point) nonzero() bool {
+	return p.f != 0 && !math.IsNaN(p.f)
+}
+
+// oppositeSigns проверяет, что значения ненулевые и разных знаков. Произведение
+// для этого не подходит: у малых значений (1e-200 * 1e-200) оно теряет значимость.
+func oppositeSigns(a, b float64) bool {
+	return a < 0 && b > 0 || a > 0 && b < 0
+}
+
+// probeZero вычисляет функцию по обе стороны от ее нуля в точке x на расстоянии
+// шага численной производной, но не дальше maxStep
+func (e *equation) probeZero(x, maxStep float64) (point, point, error) {
+	h := math.Min(solveDerivativeStep*math.Max(1, math.Abs(x)), maxStep)
+	left, err := e.eval(x - h)
+	if err != nil {
+		return point{}, point{}, err
+	}
+	right, err := e.eval(x + h)
+	if err != nil {
+		return point{}, point{}, err
+	}
+	return point{x: x - h, f: left}, point{x: x + h, f: right}, nil
+}
+
+// isolatedZero проверяет, что ноль функции в точке x - корень, а не участок,
+// где функция тождественно равна нулю или теряет значимость
+func (e *equation) isolatedZero(x float64) (bool, error) {
+	left, right, err := e.probeZero(x, math.Inf(1))
+	if err != nil {
+		return false, err
+	}
+	return left.nonzero() || right.nonzero(), nil
+}
+
+// holdsEverywhere проверяет, что уравнение, выполненное во всех узлах сетки, -
+// тождество: части равны, но не обращаются в ноль. Если обе части равны нулю,
+// нули могут быть потерей значимости, и тождество не доказано.
+func (e *equation) holdsEverywhere(a, b float64) (bool, error) {
+	for _, x := range []float64{a, b} {
+		e.calc.SetVariable(e.variable, x)
+		left, err := e.calc.eval(e.lhs)
+		if errors.Is(err, ErrDivisionByZero) {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		if left != 0 && !math.IsNaN(left) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// appendRoot добавляет корень, если он не совпадает с уже найденным
+func appendRoot(roots []Root, root Root) []Root {
+	for _, r := range roots {
+		if math.Abs(r.Value-root.Value) <= 1e-9*math.Max(1, math.Abs(root.Value)) {
+			return roots
+		}
+	}
+	return append(roots, root)
+}
+
+// newton ищет корень методом Ньютона с численной производной
+func (e *equation) newton(x float64) (Root, error) {
+	root := Root{Method: MethodNewton}
+
+	for root.Iterations < solveMaxIterations {
+		root.Iterations++
+
+		fx, err := e.eval(x)
+		if err != nil {
+			return root, err
+		}
+		if math.IsNaN(fx) || math.IsInf(fx, 0) {
+			return root, nil
+		}
+		if fx == 0 {
+			isolated, err := e.isolatedZero(x)
+			if err != nil {
+				return root, err
+			}
+			root.Value, root.Converged = x, isolated
+			return root, nil
+		}
+
+		h := solveDerivativeStep * math.Max(1, math.Abs(x))
+		fPlus, err := e.eval(x + h)
+		if err != nil {
+			return root, err
+		}
+		fMinus, err := e.eval(x - h)
+		if err != nil {
+			return root, err
+		}
+		derivative := (fPlus - fMinus) / (2 * h)
+		if derivative == 0 || math.IsNaN(derivative) || math.IsInf(derivative, 0) {
+			return root, nil
+		}
+
+		next := x - fx/derivative
+		if math.IsNaN(next) || math.IsInf(next, 0) {
+			return root, nil
+		}
+		if math.Abs(next-x) <= solveTolerance*math.Max(1, math.Abs(next)) {
+			root.Value = next
+			fNext, err := e.eval(next)
+			if err != nil {
+				return root, err
+			}
+			root.Residual = math.Abs(fNext)
+			root.Converged = root.Residual <= solveResidualFactor*math.Max(1, math.Abs(fx))
+			return root, nil
+		}
+		x = next
+	}
+
+	return root, nil
+}
+
+// brent ищет корень на отрезке со сменой знака методом Брента
+// (комбинация бисекции, секущих и обратной квадратичной интерполяции)
+func (e *equation) brent(a, b, fa, fb float64) (Root, error) {
+	root := Root{Method: MethodBrent}
+	scale := math.Max(1, math.Max(math.Abs(fa), math.Abs(fb)))
+
+	if math.Abs(fa) < math.Abs(fb) {
+		a, b, fa, fb = b, a, fb, fa
+	}
+	c, fc := a, fa
+	d := b - a
+	bisected := true
+
+	for root.Iterations < solveMaxIterations {
+		root.Iterations++
+
+		if fb == 0 || math.Abs(b-a) <= solveTolerance*math.Max(1, math.Abs(b)) {
+			break
+		}
+
+		var s float64
+		if fa != fc && fb != fc {
+			s = a*fb*fc/((fa-fb)*(fa-fc)) + b*fa*fc/((fb-fa)*(fb-fc)) + c*fa*fb/((fc-fa)*(fc-fb))
+		} else {
+			s = b - fb*(b-a)/(fb-fa)
+		}
+
+		bound := (3*a + b) / 4
+		switch {
+		case (s-bound)*(s-b) >= 0,
+			bisected && math.Abs(s-b) >= math.Abs(b-c)/2,
+			!bisected && math.Abs(s-b) >= math.Abs(c-d)/2:
+			s = (a + b) / 2
+			bisected = true
+		default:
+			bisected = false
+		}
+
+		fs, err := e.eval(s)
+		if err != nil {
+			return root, err
+		}
+		if math.IsNaN(fs) {
+			s = (a + b) / 2
+			if fs, err = e.eval(s); err != nil {
+				return root, err
+			}
+			if math.IsNaN(fs) {
+				break
+			}
+		}
+
+		d, c, fc = c, b, fb
+		if oppositeSigns(fa, fs) {
+			b, fb = s, fs
+		} else {
+			a, fa = s, fs
+		}
+		if math.Abs(fa) < math.Abs(fb) {
+			a, b, fa, fb = b, a, fb, fa
+		}
+	}
+
+	root.Value = b
+	root.Residual = math.Abs(fb)
+	root.Converged = root.Residual <= solveResidualFactor*scale
+	return root, nil
+}
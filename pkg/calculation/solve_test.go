@@ -0,0 +1,82 @@
+package calculation_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/flexer2006/y.lms_sprint1_Calc/pkg/calculation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSolveEquation(t *testing.T) {
+	guess := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name     string
+		equation string
+		params   calculation.SolveParams
+		expected []float64
+		method   string
+	}{
+		{"two roots in default range", "x^2 - 2 = 0", calculation.SolveParams{}, []float64{-math.Sqrt2, math.Sqrt2}, calculation.MethodBrent},
+		{"root in bracket", "x^2 - 2 = 0", calculation.SolveParams{Bracket: &[2]float64{0, 5}}, []float64{math.Sqrt2}, calculation.MethodBrent},
+		{"newton from guess", "x^2 - 2 = 0", calculation.SolveParams{Guess: guess(3)}, []float64{math.Sqrt2}, calculation.MethodNewton},
+		{"break-even", "25 * x = 1000 + 5 * x", calculation.SolveParams{}, []float64{50}, calculation.MethodBrent},
+		{"double root without sign change", "(x - 3) * (x - 3) = 0", calculation.SolveParams{Bracket: &[2]float64{0, 10}}, []float64{3}, ""},
+		{"pole is not a root", "1 / (x - 1) = 1", calculation.SolveParams{Bracket: &[2]float64{-5, 5}}, []float64{2}, calculation.MethodBrent},
+		{"overflow and underflow while scanning", "exp(x) = 2", calculation.SolveParams{}, []float64{math.Ln2}, calculation.MethodBrent},
+		{"roots on and between grid points", "x^3 - x = 0", calculation.SolveParams{}, []float64{-1, 0, 1}, calculation.MethodBrent},
+		{"root on grid point between roots", "sin(x) = 0", calculation.SolveParams{Bracket: &[2]float64{-4, 4}}, []float64{-math.Pi, 0, math.Pi}, calculation.MethodBrent},
+		{"root at bracket end", "x - 5 = 0", calculation.SolveParams{Bracket: &[2]float64{0, 5}}, []float64{5}, calculation.MethodBrent},
+		{"double root on grid point", "x^2 = 0", calculation.SolveParams{}, []float64{0}, calculation.MethodBrent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := calculation.SolveEquation(tt.equation, "x", tt.params)
+			require.NoError(t, err)
+			require.Len(t, result.Roots, len(tt.expected))
+
+			for i, root := range result.Roots {
+				assert.InDelta(t, tt.expected[i], root.Value, 1e-6)
+				assert.True(t, root.Converged)
+				if tt.method != "" {
+					assert.Equal(t, tt.method, root.Method)
+				}
+			}
+			assert.Positive(t, result.Evaluations)
+		})
+	}
+}
+
+func TestSolveEquation_Errors(t *testing.T) {
+	underflowGuess := -800.0
+
+	tests := []struct {
+		name     string
+		equation string
+		variable string
+		params   calculation.SolveParams
+		err      error
+	}{
+		{"no equals sign", "x^2 - 2", "x", calculation.SolveParams{}, calculation.ErrInvalidEquation},
+		{"two equals signs", "x = 1 = 2", "x", calculation.SolveParams{}, calculation.ErrInvalidEquation},
+		{"empty side", "x^2 = ", "x", calculation.SolveParams{}, calculation.ErrInvalidEquation},
+		{"inverted bracket", "x = 1", "x", calculation.SolveParams{Bracket: &[2]float64{5, 0}}, calculation.ErrInvalidBracket},
+		{"no root", "x^2 + 1 = 0", "x", calculation.SolveParams{}, calculation.ErrNoRoot},
+		{"underflow is not a root", "exp(x) = 0", "x", calculation.SolveParams{}, calculation.ErrNoRoot},
+		{"underflow in bracket is not a root", "exp(x) = 0", "x", calculation.SolveParams{Bracket: &[2]float64{-1000, -746}}, calculation.ErrNoRoot},
+		{"underflow at guess is not a root", "exp(x) = 0", "x", calculation.SolveParams{Guess: &underflowGuess}, calculation.ErrNoRoot},
+		{"identity", "x = x", "x", calculation.SolveParams{}, calculation.ErrIdentity},
+		{"identity with pole", "x / x = 1", "x", calculation.SolveParams{}, calculation.ErrIdentity},
+		{"unknown variable", "y = 1", "x", calculation.SolveParams{}, calculation.ErrUnknownVariable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := calculation.SolveEquation(tt.equation, tt.variable, tt.params)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package calculation
+
+import "math"
+
+// SpecialValuesPolicy определяет, что делать с переполнением, потерей
+// значимости и NaN в результатах операций
+type SpecialValuesPolicy int
+
+const (
+	// SpecialValuesError - ошибки ErrOverflow, ErrUnderflow и ErrNaN (по умолчанию)
+	SpecialValuesError SpecialValuesPolicy = iota
+	// SpecialValuesSaturate - переполнение дает ±MaxFloat64, потеря значимости - ноль;
+	// NaN насытить нельзя, поэтому он остается ошибкой ErrNaN
+	SpecialValuesSaturate
+	// SpecialValuesAllow - значения IEEE 754 как есть: ±Inf, денормализованные числа и NaN
+	SpecialValuesAllow
+)
+
+// specialValuesPolicies названия политик для настроек и запросов
+var specialValuesPolicies = map[string]SpecialValuesPolicy{
+	"":         SpecialValuesError,
+	"error":    SpecialValuesError,
+	"saturate": SpecialValuesSaturate,
+	"allow":    SpecialValuesAllow,
+}
+
+// ParseSpecialValuesPolicy возвращает политику по названию: error, saturate или allow
+func ParseSpecialValuesPolicy(name string) (SpecialValuesPolicy, error) {
+	policy, exists := specialValuesPolicies[name]
+	if !exists {
+		return SpecialValuesError, ErrUnknownPolicy
+	}
+	return policy, nil
+}
+
+// minNormal наименьшее положительное нормальное float64; числа меньше
+// хранятся с потерей точности
+const minNormal = 0x1p-1022
+
+// checkSpecialValue применяет политику к результату операции. lost означает,
+// что ноль получен из ненулевых операндов (1e-200 * 1e-200).
+func (c *Calculator) checkSpecialValue(x float64, lost bool) (float64, error) {
+	policy := c.options.SpecialValues
+	if policy == SpecialValuesAllow {
+		return x, nil
+	}
+
+	switch {
+	case math.IsNaN(x):
+		return 0, ErrNaN
+
+	case math.IsInf(x, 0):
+		if policy == SpecialValuesSaturate {
+			return math.Copysign(math.MaxFloat64, x), nil
+		}
+		return 0, ErrOverflow
+
+	case lost || (x != 0 && math.Abs(x) < minNormal):
+		if policy == SpecialValuesSaturate {
+			return 0, nil
+		}
+		return 0, ErrUnderflow
+	}
+	return x, nil
+}
+
+// sampler возвращает калькулятор с теми же переменными для промежуточных
+// точек интеграла и рядов: в них особые значения допустимы, а политика
+// применяется только к итоговому результату. Вложенные формы делят счетчик
+// вычислений с внешней.
+func (c *Calculator) sampler() *Calculator {
+	options := c.options
+	options.SpecialValues = SpecialValuesAllow
+	evaluations := c.evaluations
+	if evaluations == nil {
+		evaluations = new(int)
+	}
+	return &Calculator{variables: c.variables, options: options, evaluations: evaluations}
+}
+
+// checkSpecialValues применяет политику к числу или к каждому элементу матрицы
+func (c *Calculator) checkSpecialValues(v Value, lost bool) (Value, error) {
+	switch v := v.(type) {
+	case Number:
+		x, err := c.checkSpecialValue(float64(v), lost)
+		if err != nil {
+			return nil, err
+		}
+		return Number(x), nil
+
+	case *Matrix:
+		return v.mapElements(func(x float64) (float64, error) {
+			return c.checkSpecialValue(x, false)
+		})
+	}
+	return v, nil
+}
+
+// underflowed проверяет, что умножение, деление или степень конечных ненулевых
+// чисел дали ноль
+func underflowed(op rune, a, b, result Value) bool {
+	switch op {
+	case '*', '/', '^', opElementwiseMul, opElementwiseDiv, opElementwisePow:
+	default:
+		return false
+	}
+
+	x, aIsNumber := a.(Number)
+	y, bIsNumber := b.(Number)
+	z, resultIsNumber := result.(Number)
+	if !aIsNumber || !bIsNumber || !resultIsNumber || z != 0 {
+		return false
+	}
+	return x != 0 && y != 0 && !math.IsInf(float64(x), 0) && !math.IsInf(float64(y), 0)
+}
@@ -0,0 +1,157 @@
+package calculation_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/flexer2006/y.lms_sprint1_Calc/pkg/calculation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCalc_SpecialValues(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		policy      calculation.SpecialValuesPolicy
+		expected    float64
+		expectedErr error
+	}{
+		{"overflow error", "1e308 * 10", calculation.SpecialValuesError, 0, calculation.ErrOverflow},
+		{"negative overflow error", "-1e308 * 10", calculation.SpecialValuesError, 0, calculation.ErrOverflow},
+		{"function overflow error", "exp(1000)", calculation.SpecialValuesError, 0, calculation.ErrOverflow},
+		{"underflow error", "1e-200 * 1e-200", calculation.SpecialValuesError, 0, calculation.ErrUnderflow},
+		{"subnormal underflow error", "1e-300 / 1e10", calculation.SpecialValuesError, 0, calculation.ErrUnderflow},
+		{"power underflow error", "0.5^2000", calculation.SpecialValuesError, 0, calculation.ErrUnderflow},
+		{"nan error", "(-8)^(1/3)", calculation.SpecialValuesError, 0, calculation.ErrNaN},
+		{"exact zero is not underflow", "0 * 1e-300", calculation.SpecialValuesError, 0, nil},
+		{"ordinary result", "2 * 3", calculation.SpecialValuesError, 6, nil},
+		{"overflow saturate", "1e308 * 10", calculation.SpecialValuesSaturate, math.MaxFloat64, nil},
+		{"negative overflow saturate", "-1e308 * 10", calculation.SpecialValuesSaturate, -math.MaxFloat64, nil},
+		{"saturated value stays finite", "1e308 * 10 - 1e308 * 10", calculation.SpecialValuesSaturate, 0, nil},
+		{"underflow saturate", "1e-200 * 1e-200", calculation.SpecialValuesSaturate, 0, nil},
+		{"nan saturate", "(-8)^(1/3)", calculation.SpecialValuesSaturate, 0, calculation.ErrNaN},
+		{"overflow allow", "1e308 * 10", calculation.SpecialValuesAllow, math.Inf(1), nil},
+		{"subnormal allow", "1e-300 / 1e10", calculation.SpecialValuesAllow, 1e-310, nil},
+		{"nan allow", "1e308 * 10 - 1e308 * 10", calculation.SpecialValuesAllow, math.NaN(), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calc := calculation.NewCalculator()
+			calc.SetOptions(calculation.Options{SpecialValues: tt.policy})
+			result, err := calc.Calc(tt.input)
+			if tt.expectedErr != nil {
+				assert.Equal(t, tt.expectedErr, err)
+				return
+			}
+			require.NoError(t, err)
+			switch {
+			case math.IsNaN(tt.expected):
+				assert.True(t, math.IsNaN(result))
+			case math.IsInf(tt.expected, 0):
+				assert.Equal(t, tt.expected, result)
+			default:
+				assert.InDelta(t, tt.expected, result, math.Abs(tt.expected)*1e-12)
+			}
+		})
+	}
+}
+
+func TestEvaluate_SpecialValuesMatrix(t *testing.T) {
+	_, err := calculation.Evaluate("[1e308, 1] * 10")
+	assert.Equal(t, calculation.ErrOverflow, err)
+
+	calc := calculation.NewCalculator()
+	calc.SetOptions(calculation.Options{SpecialValues: calculation.SpecialValuesSaturate})
+	result, err := calc.Evaluate("[1e308, 1] * 10")
+	require.NoError(t, err)
+	assert.Equal(t, [][]float64{{math.MaxFloat64, 10}}, result.(*calculation.Matrix).ToRows())
+}
+
+func TestCalcQuantity_SpecialValues(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		policy      calculation.SpecialValuesPolicy
+		expected    float64
+		expectedErr error
+	}{
+		{"overflow error", "1e308 * 10", calculation.SpecialValuesError, 0, calculation.ErrOverflow},
+		{"overflow with units error", "1e308 m * 10", calculation.SpecialValuesError, 0, calculation.ErrOverflow},
+		{"function overflow error", "exp(1000)", calculation.SpecialValuesError, 0, calculation.ErrOverflow},
+		{"underflow error", "1e-200 * 1e-200", calculation.SpecialValuesError, 0, calculation.ErrUnderflow},
+		{"nan error", "(-8)^(1/3)", calculation.SpecialValuesError, 0, calculation.ErrNaN},
+		{"overflow saturate", "1e308 m * 10", calculation.SpecialValuesSaturate, math.MaxFloat64, nil},
+		{"underflow saturate", "1e-200 m * 1e-200", calculation.SpecialValuesSaturate, 0, nil},
+		{"overflow allow", "1e308 * 10", calculation.SpecialValuesAllow, math.Inf(1), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calc := calculation.NewCalculator()
+			calc.SetOptions(calculation.Options{SpecialValues: tt.policy})
+			result, err := calc.CalcQuantity(tt.input)
+			if tt.expectedErr != nil {
+				assert.Equal(t, tt.expectedErr, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result.Value)
+		})
+	}
+}
+
+func TestCalcUncertain_SpecialValues(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		policy      calculation.SpecialValuesPolicy
+		expected    float64
+		expectedErr error
+	}{
+		{"overflow error", "1e308±1e300 * 10", calculation.SpecialValuesError, 0, calculation.ErrOverflow},
+		{"function overflow error", "exp(1000±1)", calculation.SpecialValuesError, 0, calculation.ErrOverflow},
+		{"underflow error", "1e-200±1e-201 * 1e-200", calculation.SpecialValuesError, 0, calculation.ErrUnderflow},
+		{"nan error", "(-8)^(1/3)", calculation.SpecialValuesError, 0, calculation.ErrNaN},
+		{"overflow saturate", "1e308 * 10", calculation.SpecialValuesSaturate, math.MaxFloat64, nil},
+		{"underflow saturate", "1e-200 * 1e-200", calculation.SpecialValuesSaturate, 0, nil},
+		{"overflow allow", "1e308 * 10", calculation.SpecialValuesAllow, math.Inf(1), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calc := calculation.NewCalculator()
+			calc.SetOptions(calculation.Options{SpecialValues: tt.policy})
+			result, err := calc.CalcUncertain(tt.input)
+			if tt.expectedErr != nil {
+				assert.Equal(t, tt.expectedErr, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, result.Value)
+		})
+	}
+}
+
+func TestParseSpecialValuesPolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		expected    calculation.SpecialValuesPolicy
+		expectedErr error
+	}{
+		{"", calculation.SpecialValuesError, nil},
+		{"error", calculation.SpecialValuesError, nil},
+		{"saturate", calculation.SpecialValuesSaturate, nil},
+		{"allow", calculation.SpecialValuesAllow, nil},
+		{"ignore", calculation.SpecialValuesError, calculation.ErrUnknownPolicy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy, err := calculation.ParseSpecialValuesPolicy(tt.name)
+			assert.Equal(t, tt.expectedErr, err)
+			assert.Equal(t, tt.expected, policy)
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package calculation
+
+import (
+	"math"
+	"sort"
+)
+
+// aggregate статистическая функция над списком значений
+type aggregate struct {
+	params int                                             // Количество числовых параметров после списка
+	call   func(values, params []float64) (float64, error) // Вычисление функции
+}
+
+// aggregates определяет статистические функции. Аргументы-списки и матрицы
+// разворачиваются в один список: mean([3, 5, 8]) = mean(3, 5, 8)
+var aggregates = map[string]aggregate{
+	"mean": {0, func(values, _ []float64) (float64, error) {
+		return mean(values), nil
+	}},
+	"median": {0, func(values, _ []float64) (float64, error) {
+		return percentile(values, 50), nil
+	}},
+	"variance": {0, func(values, _ []float64) (float64, error) {
+		return variance(values)
+	}},
+	"stdev": {0, func(values, _ []float64) (float64, error) {
+		v, err := variance(values)
+		return math.Sqrt(v), err
+	}},
+	// percentile(list, p) - перцентиль p от 0 до 100 с линейной интерполяцией
+	"percentile": {1, func(values, params []float64) (float64, error) {
+		p := params[0]
+		if p < 0 || p > 100 {
+			return 0, ErrInvalidArgument
+		}
+		return percentile(values, p), nil
+	}},
+	"sum": {0, func(values, _ []float64) (float64, error) {
+		sum := 0.0
+		for _, value := range values {
+			sum += value
+		}
+		return sum, nil
+	}},
+	"count": {0, func(values, _ []float64) (float64, error) {
+		return float64(len(values)), nil
+	}},
+	"min": {0, func(values, _ []float64) (float64, error) {
+		result := values[0]
+		for _, value := range values[1:] {
+			result = math.Min(result, value)
+		}
+		return result, nil
+	}},
+	"max": {0, func(values, _ []float64) (float64, error) {
+		result := values[0]
+		for _, value := range values[1:] {
+			result = math.Max(result, value)
+		}
+		return result, nil
+	}},
+}
+
+// apply разворачивает аргументы в список и вычисляет функцию
+func (a aggregate) apply(args []Value) (Value, error) {
+	if len(args) <= a.params {
+		return nil, ErrArgumentCount
+	}
+
+	params := make([]float64, a.params)
+	for i, arg := range args[len(args)-a.params:] {
+		number, ok := arg.(Number)
+		if !ok {
+			return nil, ErrNotScalar
+		}
+		params[i] = float64(number)
+	}
+
+	var values []float64
+	for _, arg := range args[:len(args)-a.params] {
+		switch arg := arg.(type) {
+		case Number:
+			values = append(values, float64(arg))
+		case *Matrix:
+			values = append(values, arg.Data...)
+		default:
+			return nil, ErrNotScalar
+		}
+	}
+	if len(values) == 0 {
+		return nil, ErrArgumentCount
+	}
+
+	result, err := a.call(values, params)
+	if err != nil {
+		return nil, err
+	}
+	return Number(result), nil
+}
+
+// isSpecialFormCall отличает вызов специальной формы от одноименной статистической
+// функции: ряд sum(k^2, k, 1, 10) - вызов с четырьмя аргументами, второй из
+// которых - имя, не занятое переменной или константой; остальные - сумма
+// значений sum([1, 2, 3]), sum(1, 2, 3, 4)
+func (c *Calculator) isSpecialFormCall(n callNode) bool {
+	if _, exists := aggregates[n.name]; !exists {
+		return true
+	}
+	if len(n.args) != 4 {
+		return false
+	}
+	variable, ok := n.args[1].(variableNode)
+	if !ok {
+		return false
+	}
+	if _, exists := c.variables[variable.name]; exists {
+		return false
+	}
+	_, exists := constants[variable.name]
+	return !exists
+}
+
+// mean вычисляет среднее арифметическое
+func mean(values []float64) float64 {
+	sum := 0.0
+	for _, value := range values {
+		sum += value
+	}
+	return sum / float64(len(values))
+}
+
+// variance вычисляет выборочную дисперсию (с делителем n-1)
+func variance(values []float64) (float64, error) {
+	if len(values) < 2 {
+		return 0, ErrInvalidArgument
+	}
+
+	average := mean(values)
+	sum := 0.0
+	for _, value := range values {
+		sum += (value - average) * (value - average)
+	}
+	return sum / float64(len(values)-1), nil
+}
+
+// percentile вычисляет перцентиль с линейной интерполяцией между соседними значениями
+func percentile(values []float64, p float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	position := p / 100 * float64(len(sorted)-1)
+	lower := int(math.Floor(position))
+	if lower >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	fraction := position - float64(lower)
+	return sorted[lower] + fraction*(sorted[lower+1]-sorted[lower])
+}
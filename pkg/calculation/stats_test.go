@@ -0,0 +1,58 @@
+package calculation_test
+
+import (
+	"testing"
+
+	"github.com/flexer2006/y.lms_sprint1_Calc/pkg/calculation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalc_Statistics(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    float64
+		expectedErr error
+	}{
+		{"mean", "mean([3, 5, 8]) * 1.2", 6.4, nil},
+		{"mean of arguments", "mean(1, 2, 3, 6)", 3, nil},
+		{"median odd", "median([7, 1, 3])", 3, nil},
+		{"median even", "median([4, 1, 3, 2])", 2.5, nil},
+		{"variance", "variance([2, 4, 4, 4, 5, 5, 7, 9])", 32.0 / 7, nil},
+		{"stdev", "stdev([1, 3])", 1.4142135623730951, nil},
+		{"percentile", "percentile([1, 2, 3, 4, 5], 25)", 2, nil},
+		{"percentile interpolated", "percentile([10, 20], 75)", 17.5, nil},
+		{"sum of list", "sum([1, 2, 3])", 6, nil},
+		{"sum series", "sum(k, k, 1, 4)", 10, nil},
+		{"sum of four numbers", "sum(1, 2, 3, 4)", 10, nil},
+		{"sum of four expressions", "sum(1, 2 * 1, 3, 4)", 10, nil},
+		{"constant is not a series variable", "sum(1, pi, 3, 4)", 8 + 3.141592653589793, nil},
+		{"count", "count([1, 2, 3, 4, 5])", 5, nil},
+		{"matrix flattened", "sum([1, 2; 3, 4])", 10, nil},
+		{"min of list", "min([4, -1, 7])", -1, nil},
+		{"list expressions", "max([1 + 1, 2 * 3])", 6, nil},
+		{"variance of single value", "variance([5])", 0, calculation.ErrInvalidArgument},
+		{"percentile out of range", "percentile([1, 2], 101)", 0, calculation.ErrInvalidArgument},
+		{"percentile without list", "percentile(50)", 0, calculation.ErrArgumentCount},
+		{"list is not a number", "[1, 2, 3] + 0", 0, calculation.ErrNotScalar},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := calculation.Calc(tt.input)
+			if tt.expectedErr != nil {
+				assert.Equal(t, tt.expectedErr, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.InDelta(t, tt.expected, result, 1e-9)
+		})
+	}
+}
+
+func TestCalc_StatisticsDefinedVariable(t *testing.T) {
+	// Определенная переменная во второй позиции - значение, а не переменная ряда
+	result, err := calculation.CalcWithVariables("sum(1, m, 3, 4)", map[string]float64{"m": 2})
+	assert.NoError(t, err)
+	assert.InDelta(t, 10, result, 1e-9)
+}
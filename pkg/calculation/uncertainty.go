@@ -0,0 +1,246 @@
+package calculation
+
+import "math"
+
+// uncertaintyDerivativeStep относительный шаг численной производной встроенных функций
+const uncertaintyDerivativeStep = 1e-6
+
+// Uncertain результат вычисления со стандартной неопределенностью
+type Uncertain struct {
+	Value       float64 // Значение
+	Uncertainty float64 // Стандартная неопределенность
+}
+
+// uncertain значение во время вычисления: частные производные по независимым
+// источникам погрешности (каждый литерал вида 3.2±0.1) позволяют учесть
+// корреляцию, если одна величина входит в выражение несколько раз
+type uncertain struct {
+	value float64
+	grad  map[int]float64
+}
+
+// uncertaintyEvaluator вычисляет выражение с линейным распространением погрешностей
+type uncertaintyEvaluator struct {
+	calc    *Calculator
+	sources int // Количество независимых источников погрешности
+}
+
+// CalcUncertain вычисляет выражение, в котором литералы могут нести погрешность:
+// 3.2±0.1 * 4.0±0.2. Неопределенность результата вычисляется линейным
+// распространением погрешностей через все операторы и встроенные функции.
+func CalcUncertain(expression string) (Uncertain, error) {
+	return NewCalculator().CalcUncertain(expression)
+}
+
+// CalcUncertain вычисляет выражение с погрешностями с учетом заданных переменных
+func (c *Calculator) CalcUncertain(expression string) (Uncertain, error) {
+	root, err := c.parse(expression, parseOptions{uncertainty: true})
+	if err != nil {
+		return Uncertain{}, err
+	}
+
+	evaluator := &uncertaintyEvaluator{calc: c}
+	result, err := evaluator.eval(root)
+	if err != nil {
+		return Uncertain{}, err
+	}
+	return Uncertain{Value: result.value, Uncertainty: result.sigma()}, nil
+}
+
+// sigma вычисляет стандартную неопределенность по частным производным
+func (u uncertain) sigma() float64 {
+	sum := 0.0
+	for _, derivative := range u.grad {
+		sum += derivative * derivative
+	}
+	return math.Sqrt(sum)
+}
+
+// combine строит градиент как линейную комбинацию градиентов операндов
+func combine(da float64, a map[int]float64, db float64, b map[int]float64) map[int]float64 {
+	result := make(map[int]float64, len(a)+len(b))
+	for source, derivative := range a {
+		result[source] += da * derivative
+	}
+	for source, derivative := range b {
+		result[source] += db * derivative
+	}
+	return result
+}
+
+// eval вычисляет узел синтаксического дерева вместе с частными производными
+func (e *uncertaintyEvaluator) eval(n node) (uncertain, error) {
+	switch n := n.(type) {
+	case numberNode:
+		return uncertain{value: n.value}, nil
+
+	case variableNode:
+		if value, exists := e.calc.variables[n.name]; exists {
+			return uncertain{value: value}, nil
+		}
+		if value, exists := constants[n.name]; exists {
+			return uncertain{value: value}, nil
+		}
+		return uncertain{}, ErrUnknownVariable
+
+	case unaryNode:
+		operand, err := e.eval(n.operand)
+		if err != nil {
+			return uncertain{}, err
+		}
+		return uncertain{value: -operand.value, grad: combine(-1, operand.grad, 0, nil)}, nil
+
+	case binaryNode:
+		a, err := e.eval(n.left)
+		if err != nil {
+			return uncertain{}, err
+		}
+		b, err := e.eval(n.right)
+		if err != nil {
+			return uncertain{}, err
+		}
+		result, err := e.applyOperation(n.op, a, b)
+		if err != nil {
+			return uncertain{}, err
+		}
+		result.value, err = e.calc.checkSpecialValue(result.value, underflowed(n.op, Number(a.value), Number(b.value), Number(result.value)))
+		return result, err
+
+	case callNode:
+		result, err := e.call(n)
+		if err != nil {
+			return uncertain{}, err
+		}
+		result.value, err = e.calc.checkSpecialValue(result.value, false)
+		return result, err
+
+	default:
+		return uncertain{}, ErrInvalidExpression
+	}
+}
+
+// applyOperation применяет бинарную операцию и вычисляет производные результата
+func (e *uncertaintyEvaluator) applyOperation(op rune, a, b uncertain) (uncertain, error) {
+	switch op {
+	case '+':
+		return uncertain{value: a.value + b.value, grad: combine(1, a.grad, 1, b.grad)}, nil
+
+	case '-':
+		return uncertain{value: a.value - b.value, grad: combine(1, a.grad, -1, b.grad)}, nil
+
+	case '*':
+		return uncertain{value: a.value * b.value, grad: combine(b.value, a.grad, a.value, b.grad)}, nil
+
+	case '/':
+		if b.value == 0 {
+			return uncertain{}, ErrDivisionByZero
+		}
+		value := a.value / b.value
+		return uncertain{value: value, grad: combine(1/b.value, a.grad, -value/b.value, b.grad)}, nil
+
+	case '^':
+		value := math.Pow(a.value, b.value)
+		da := b.value * math.Pow(a.value, b.value-1)
+		if len(a.grad) == 0 {
+			da = 0
+		}
+		db := 0.0
+		if len(b.grad) > 0 {
+			if a.value <= 0 {
+				return uncertain{}, ErrInvalidArgument
+			}
+			db = value * math.Log(a.value)
+		}
+		return uncertain{value: value, grad: combine(da, a.grad, db, b.grad)}, nil
+
+	case opPercentAdd, opPercentSub:
+		sign := 1.0
+		if op == opPercentSub {
+			sign = -1
+		}
+		value := a.value + sign*a.value*b.value/100
+		return uncertain{value: value, grad: combine(1+sign*b.value/100, a.grad, sign*a.value/100, b.grad)}, nil
+
+	case '±':
+		// Погрешность сама по себе должна быть точным числом
+		if len(b.grad) > 0 {
+			return uncertain{}, ErrInvalidArgument
+		}
+		grad := combine(1, a.grad, 0, nil)
+		grad[e.sources] = math.Abs(b.value)
+		e.sources++
+		return uncertain{value: a.value, grad: grad}, nil
+
+	default:
+		return uncertain{}, ErrInvalidOperator
+	}
+}
+
+// call вычисляет встроенную функцию; частные производные по аргументам
+// находятся численно, поэтому работают для любой функции реестра
+func (e *uncertaintyEvaluator) call(n callNode) (uncertain, error) {
+	fn, exists := functions[n.name]
+	if !exists {
+		return uncertain{}, ErrUnknownFunction
+	}
+	if len(n.args) < fn.minArgs || (fn.maxArgs >= 0 && len(n.args) > fn.maxArgs) {
+		return uncertain{}, ErrArgumentCount
+	}
+
+	args := make([]uncertain, len(n.args))
+	values := make([]float64, len(n.args))
+	for i, arg := range n.args {
+		value, err := e.eval(arg)
+		if err != nil {
+			return uncertain{}, err
+		}
+		args[i], values[i] = value, value.value
+	}
+
+	value, err := fn.call(values)
+	if err != nil {
+		return uncertain{}, err
+	}
+
+	result := uncertain{value: value}
+	for i, arg := range args {
+		if len(arg.grad) == 0 {
+			continue
+		}
+		derivative, err := partialDerivative(fn, values, i)
+		if err != nil {
+			return uncertain{}, err
+		}
+		result.grad = combine(1, result.grad, derivative, arg.grad)
+	}
+	return result, nil
+}
+
+// partialDerivative вычисляет частную производную функции по i-му аргументу
+// центральной разностью, а на границе области определения - односторонней
+func partialDerivative(fn function, values []float64, i int) (float64, error) {
+	h := uncertaintyDerivativeStep * math.Max(1, math.Abs(values[i]))
+	at := func(x float64) (float64, error) {
+		shifted := append([]float64(nil), values...)
+		shifted[i] = x
+		return fn.call(shifted)
+	}
+
+	center, err := at(values[i])
+	if err != nil {
+		return 0, err
+	}
+	plus, errPlus := at(values[i] + h)
+	minus, errMinus := at(values[i] - h)
+
+	switch {
+	case errPlus == nil && errMinus == nil:
+		return (plus - minus) / (2 * h), nil
+	case errPlus == nil:
+		return (plus - center) / h, nil
+	case errMinus == nil:
+		return (center - minus) / h, nil
+	default:
+		return 0, ErrInvalidArgument
+	}
+}
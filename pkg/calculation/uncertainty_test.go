@@ -0,0 +1,66 @@
+package calculation_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/flexer2006/y.lms_sprint1_Calc/pkg/calculation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalcUncertain(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    float64
+		uncertainty float64
+		err         error
+	}{
+		{"product", "3.2±0.1 * 4.0±0.2", 12.8, math.Hypot(4.0*0.1, 3.2*0.2), nil},
+		{"ascii sign", "3.2+/-0.1 * 4.0+/-0.2", 12.8, math.Hypot(4.0*0.1, 3.2*0.2), nil},
+		{"sum", "10±0.3 + 5±0.4", 15, 0.5, nil},
+		{"exact number", "2 * 3", 6, 0, nil},
+		{"scaling", "2 * (1.5±0.1)", 3, 0.2, nil},
+		{"division", "10±1 / 2", 5, 0.5, nil},
+		{"power", "(2±0.1)^3", 8, 3 * 4 * 0.1, nil},
+		{"function", "sqrt(16±0.8)", 4, 0.1, nil},
+		{"trigonometry", "sin(0±0.01)", 0, 0.01, nil},
+		{"negative uncertainty", "5±-0.5", 5, 0.5, nil},
+		{"division by zero", "1 / (0±0.1)", 0, 0, calculation.ErrDivisionByZero},
+		{"uncertain uncertainty", "1±(1±1)", 0, 0, calculation.ErrInvalidArgument},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := calculation.CalcUncertain(tt.input)
+
+			if tt.err != nil {
+				assert.Equal(t, tt.err, err)
+			} else {
+				assert.NoError(t, err)
+				assert.InDelta(t, tt.expected, result.Value, 1e-9)
+				assert.InDelta(t, tt.uncertainty, result.Uncertainty, 1e-6)
+			}
+		})
+	}
+}
+
+func TestCalcUncertain_IndependentLiterals(t *testing.T) {
+	calc := calculation.NewCalculator()
+	calc.SetVariable("k", 2)
+
+	// Каждый литерал - независимый источник, поэтому погрешности складываются в квадратуре
+	result, err := calc.CalcUncertain("(5±0.5) - (5±0.5) * k")
+	assert.NoError(t, err)
+	assert.InDelta(t, -5, result.Value, 1e-9)
+	assert.InDelta(t, math.Hypot(0.5, 1), result.Uncertainty, 1e-9)
+
+	result, err = calc.CalcUncertain("sum(k, k, 1, 2)")
+	assert.Equal(t, calculation.ErrUnknownFunction, err)
+	assert.Zero(t, result)
+}
+
+func TestCalc_UncertaintySignIsRejected(t *testing.T) {
+	_, err := calculation.Calc("3.2±0.1")
+	assert.Error(t, err)
+}
@@ -0,0 +1,158 @@
+package calculation_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/flexer2006/y.lms_sprint1_Calc/pkg/calculation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalc_UnicodeSymbols(t *testing.T) {
+	tests := []struct {
+		name        string
+		input       string
+		expected    float64
+		expectedErr error
+	}{
+		{"multiplication sign", "3 × 4", 12, nil},
+		{"middle dot", "3 · 4", 12, nil},
+		{"division sign", "12 ÷ 4", 3, nil},
+		{"minus sign", "5 − 7", -2, nil},
+		{"unary minus sign", "−5 + 1", -4, nil},
+		{"square root", "√16", 4, nil},
+		{"square root of expression", "√(9 + 16)", 5, nil},
+		{"square root before power", "√3²", 3, nil},
+		{"pi", "2 × π", 2 * math.Pi, nil},
+		{"superscript", "3²", 9, nil},
+		{"multi-digit superscript", "2¹⁰", 1024, nil},
+		{"negative superscript", "10⁻³", 0.001, nil},
+		{"superscript of parentheses", "(1 + 2)³", 27, nil},
+		{"superscript in sum", "3² + 4²", 25, nil},
+		{"cyrillic variable", "цена × 2", 200, nil},
+		{"underscore grouping", "1_000_000 / 1_000", 1000, nil},
+		{"space grouping", "1 000 000 + 1", 1000001, nil},
+		{"non-breaking space grouping", "1 000 × 2", 2000, nil},
+		{"grouping with fraction", "12 345.5 × 2", 24691, nil},
+		{"leading dot", ".5 + .25", 0.75, nil},
+		{"misplaced superscript minus", "2³⁻", 0, calculation.ErrInvalidExpression},
+		{"invalid group", "1 00", 0, calculation.ErrInvalidExpression},
+		{"invalid utf-8", "2 + \xff", 0, calculation.ErrInvalidCharacter},
+		{"unknown symbol", "2 ≈ 3", 0, calculation.ErrInvalidCharacter},
+		{"pi without implicit multiplication", "2π", 0, calculation.ErrInvalidExpression},
+		{"pi before variable without implicit multiplication", "πr", 0, calculation.ErrInvalidExpression},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calc := calculation.NewCalculator()
+			calc.SetVariable("цена", 100)
+			calc.SetVariable("r", 2)
+
+			result, err := calc.Calc(tt.input)
+			if tt.expectedErr != nil {
+				assert.Equal(t, tt.expectedErr, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.InDelta(t, tt.expected, result, 1e-9)
+		})
+	}
+}
+
+func TestCalc_Locale(t *testing.T) {
+	tests := []struct {
+		name     string
+		locale   string
+		input    string
+		expected float64
+	}{
+		{"decimal comma", "ru-RU", "3,5 + 1,25", 4.75},
+		{"grouping with decimal comma", "ru-RU", "1 000,5 × 2", 2001},
+		{"decimal point in arguments", "ru-RU", "max(1.5, 2.5)", 2.5},
+		{"two arguments", "ru-RU", "nCr(5, 2)", 10},
+		{"four arguments", "ru-RU", "sum(i, i, 1, 3)", 6},
+		{"decimal comma in parentheses inside arguments", "ru-RU", "max((1,5), 1)", 1.5},
+		{"decimal comma in matrix inside arguments", "ru-RU", "sum([1,5, 2])", 3.5},
+		{"matrix with spaces", "ru", "sum([1, 2; 3, 4])", 10},
+		{"underscore locale", "de_DE", "0,5 × 4", 2},
+		{"decimal point still accepted", "ru-RU", "3.5 × 2", 7},
+		{"english keeps comma as separator", "en-US", "max(1,5)", 5},
+		{"no locale", "", "max(1,5)", 5},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calc := calculation.NewCalculator()
+			calc.SetOptions(calculation.Options{Locale: tt.locale})
+
+			result, err := calc.Calc(tt.input)
+			assert.NoError(t, err)
+			assert.InDelta(t, tt.expected, result, 1e-9)
+		})
+	}
+}
+
+func TestCalc_LocaleAmbiguousComma(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		position int
+	}{
+		{"two arguments", "nCr(5,2)", 5},
+		{"four arguments", "sum(i,i,1,3)", 9},
+		{"decimal comma in argument", "max(1,5, 2)", 5},
+		{"nested call", "max(1, abs(2,5))", 12},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calc := calculation.NewCalculator()
+			calc.SetOptions(calculation.Options{Locale: "ru-RU"})
+
+			_, err := calc.Calc(tt.input)
+			assert.ErrorIs(t, err, calculation.ErrAmbiguousComma)
+			var syntaxErr *calculation.SyntaxError
+			if assert.ErrorAs(t, err, &syntaxErr) {
+				assert.Equal(t, tt.position, syntaxErr.Position)
+			}
+		})
+	}
+}
+
+func TestCalc_UnicodeImplicitMultiplication(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected float64
+	}{
+		{"number before pi", "2π", 2 * math.Pi},
+		{"pi before variable", "πr", 2 * math.Pi},
+		{"number, pi and variable", "2πr", 4 * math.Pi},
+		{"pi and square root", "2πr + 3√4", 4*math.Pi + 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calc := calculation.NewCalculator()
+			calc.SetOptions(calculation.Options{ImplicitMultiplication: true})
+			calc.SetVariable("r", 2)
+
+			result, err := calc.Calc(tt.input)
+			assert.NoError(t, err)
+			assert.InDelta(t, tt.expected, result, 1e-9)
+		})
+	}
+}
+
+func TestCalcInterval_GroupedLiteral(t *testing.T) {
+	result, err := calculation.CalcInterval("1 000,1 - 1000")
+	assert.Equal(t, calculation.ErrInvalidExpression, err)
+
+	calc := calculation.NewCalculator()
+	calc.SetOptions(calculation.Options{Locale: "ru-RU"})
+	result, err = calc.CalcInterval("1 000,1 - 1000")
+	assert.NoError(t, err)
+	assert.LessOrEqual(t, result.Lower, 0.1)
+	assert.GreaterOrEqual(t, result.Upper, 0.1)
+}
@@ -0,0 +1,428 @@
+package calculation
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// dimension показатели степени базовых величин СИ
+type dimension [7]int
+
+// Индексы базовых величин в dimension
+const (
+	dimLength      = iota // Длина, м
+	dimMass               // Масса, кг
+	dimTime               // Время, с
+	dimCurrent            // Сила тока, А
+	dimTemperature        // Температура, К
+	dimAmount             // Количество вещества, моль
+	dimLuminosity         // Сила света, кд
+)
+
+// baseUnitNames обозначения базовых единиц СИ для вывода результата
+var baseUnitNames = [7]string{"m", "kg", "s", "A", "K", "mol", "cd"}
+
+// unit описывает единицу измерения в реестре
+type unit struct {
+	scale      float64   // Значение единицы в базовых единицах СИ
+	dim        dimension // Размерность
+	prefixable bool      // Допускает приставки СИ (km, ms, kN)
+}
+
+// units реестр поддерживаемых единиц измерения
+var units = map[string]unit{
+	// Базовые единицы СИ (масса задается через грамм, чтобы работали приставки)
+	"m":   {1, dimension{dimLength: 1}, true},
+	"g":   {1e-3, dimension{dimMass: 1}, true},
+	"s":   {1, dimension{dimTime: 1}, true},
+	"A":   {1, dimension{dimCurrent: 1}, true},
+	"K":   {1, dimension{dimTemperature: 1}, true},
+	"mol": {1, dimension{dimAmount: 1}, true},
+	"cd":  {1, dimension{dimLuminosity: 1}, true},
+
+	// Производные единицы СИ
+	"N":  {1, dimension{dimMass: 1, dimLength: 1, dimTime: -2}, true},
+	"J":  {1, dimension{dimMass: 1, dimLength: 2, dimTime: -2}, true},
+	"W":  {1, dimension{dimMass: 1, dimLength: 2, dimTime: -3}, true},
+	"Pa": {1, dimension{dimMass: 1, dimLength: -1, dimTime: -2}, true},
+	"Hz": {1, dimension{dimTime: -1}, true},
+	"C":  {1, dimension{dimCurrent: 1, dimTime: 1}, true},
+	"V":  {1, dimension{dimMass: 1, dimLength: 2, dimTime: -3, dimCurrent: -1}, true},
+	"L":  {1e-3, dimension{dimLength: 3}, true},
+	"l":  {1e-3, dimension{dimLength: 3}, true},
+	"t":  {1e3, dimension{dimMass: 1}, true},
+
+	// Внесистемные единицы
+	"min": {60, dimension{dimTime: 1}, false},
+	"h":   {3600, dimension{dimTime: 1}, false},
+	"d":   {86400, dimension{dimTime: 1}, false},
+	"in":  {0.0254, dimension{dimLength: 1}, false},
+	"ft":  {0.3048, dimension{dimLength: 1}, false},
+	"yd":  {0.9144, dimension{dimLength: 1}, false},
+	"mi":  {1609.344, dimension{dimLength: 1}, false},
+	"nmi": {1852, dimension{dimLength: 1}, false},
+	"lb":  {0.45359237, dimension{dimMass: 1}, false},
+	"oz":  {0.028349523125, dimension{dimMass: 1}, false},
+	"gal": {3.785411784e-3, dimension{dimLength: 3}, false},
+	"ha":  {1e4, dimension{dimLength: 2}, false},
+	"mph": {0.44704, dimension{dimLength: 1, dimTime: -1}, false},
+}
+
+// unitPrefixes приставки СИ; двухбуквенная "da" проверяется раньше "d"
+var unitPrefixes = []struct {
+	name  string
+	scale float64
+}{
+	{"da", 1e1}, {"Y", 1e24}, {"Z", 1e21}, {"E", 1e18}, {"P", 1e15}, {"T", 1e12},
+	{"G", 1e9}, {"M", 1e6}, {"k", 1e3}, {"h", 1e2}, {"d", 1e-1}, {"c", 1e-2},
+	{"m", 1e-3}, {"u", 1e-6}, {"µ", 1e-6}, {"n", 1e-9}, {"p", 1e-12}, {"f", 1e-15},
+	{"a", 1e-18}, {"z", 1e-21}, {"y", 1e-24},
+}
+
+// lookupUnit ищет единицу по имени, в том числе с приставкой СИ
+func lookupUnit(name string) (unit, bool) {
+	if u, exists := units[name]; exists {
+		return u, true
+	}
+
+	for _, prefix := range unitPrefixes {
+		base, found := strings.CutPrefix(name, prefix.name)
+		if !found {
+			continue
+		}
+		if u, exists := units[base]; exists && u.prefixable {
+			u.scale *= prefix.scale
+			return u, true
+		}
+	}
+
+	return unit{}, false
+}
+
+// Quantity результат вычисления с единицами измерения
+type Quantity struct {
+	Value float64 // Значение в единицах Unit
+	Unit  string  // Единица измерения; пустая для безразмерных величин
+}
+
+// quantity значение с размерностью во время вычисления
+type quantity struct {
+	value float64        // Значение в базовых единицах СИ
+	dim   dimension      // Размерность
+	units map[string]int // Единицы для вывода результата и их степени (km/h -> km:1, h:-1)
+}
+
+// CalcQuantity вычисляет выражение с единицами измерения: 5 km + 300 m, 60 km/h * 2 h, to(3 ft, m).
+// Сложение и вычитание требуют одинаковой размерности и выражают результат в единицах левого операнда.
+func CalcQuantity(expression string) (Quantity, error) {
+	return NewCalculator().CalcQuantity(expression)
+}
+
+// CalcQuantity вычисляет выражение с единицами измерения с учетом заданных переменных
+func (c *Calculator) CalcQuantity(expression string) (Quantity, error) {
+	root, err := c.parse(expression, parseOptions{quantities: true})
+	if err != nil {
+		return Quantity{}, err
+	}
+
+	result, err := c.evalQuantity(root)
+	if err != nil {
+		return Quantity{}, err
+	}
+	return result.export(), nil
+}
+
+// evalQuantity вычисляет узел синтаксического дерева с учетом размерностей
+func (c *Calculator) evalQuantity(n node) (quantity, error) {
+	switch n := n.(type) {
+	case numberNode:
+		return quantity{value: n.value}, nil
+
+	case variableNode:
+		if value, exists := c.variables[n.name]; exists {
+			return quantity{value: value}, nil
+		}
+		if u, exists := lookupUnit(n.name); exists {
+			return quantity{value: u.scale, dim: u.dim, units: map[string]int{n.name: 1}}, nil
+		}
+		if value, exists := constants[n.name]; exists {
+			return quantity{value: value}, nil
+		}
+		return quantity{}, ErrUnknownUnit
+
+	case unaryNode:
+		operand, err := c.evalQuantity(n.operand)
+		if err != nil {
+			return quantity{}, err
+		}
+		operand.value = -operand.value
+		return operand, nil
+
+	case binaryNode:
+		a, err := c.evalQuantity(n.left)
+		if err != nil {
+			return quantity{}, err
+		}
+		b, err := c.evalQuantity(n.right)
+		if err != nil {
+			return quantity{}, err
+		}
+		result, err := applyQuantityOperation(n.op, a, b)
+		if err != nil {
+			return quantity{}, err
+		}
+		result.value, err = c.checkSpecialValue(result.value, underflowed(n.op, Number(a.value), Number(b.value), Number(result.value)))
+		return result, err
+
+	case callNode:
+		result, err := c.callQuantity(n)
+		if err != nil {
+			return quantity{}, err
+		}
+		result.value, err = c.checkSpecialValue(result.value, false)
+		return result, err
+
+	default:
+		return quantity{}, ErrInvalidExpression
+	}
+}
+
+// applyQuantityOperation применяет бинарную операцию к величинам с проверкой размерностей
+func applyQuantityOperation(op rune, a, b quantity) (quantity, error) {
+	switch op {
+	case '+', '-':
+		if a.dim != b.dim {
+			return quantity{}, ErrDimensionMismatch
+		}
+		value, err := operators[op].operation(a.value, b.value)
+		if err != nil {
+			return quantity{}, err
+		}
+		if a.isDimensionless() {
+			a.units = b.units
+		}
+		return quantity{value: value, dim: a.dim, units: a.units}, nil
+
+	case '*', '/':
+		value, err := operators[op].operation(a.value, b.value)
+		if err != nil {
+			return quantity{}, err
+		}
+		sign := 1
+		if op == '/' {
+			sign = -1
+		}
+		result := quantity{value: value, units: make(map[string]int)}
+		for i := range result.dim {
+			result.dim[i] = a.dim[i] + sign*b.dim[i]
+		}
+		for name, power := range a.units {
+			result.units[name] += power
+		}
+		for name, power := range b.units {
+			result.units[name] += sign * power
+		}
+		return result.normalize(), nil
+
+	case '^':
+		if !b.isDimensionless() {
+			return quantity{}, ErrDimensionMismatch
+		}
+		return a.pow(b.value)
+
+	case opPercentAdd, opPercentSub:
+		// Процент безразмерный, результат в единицах a: 5 km + 10%
+		if !b.isDimensionless() {
+			return quantity{}, ErrDimensionMismatch
+		}
+		value, err := operators[op].operation(a.value, b.value)
+		if err != nil {
+			return quantity{}, err
+		}
+		return quantity{value: value, dim: a.dim, units: a.units}, nil
+
+	default:
+		return quantity{}, ErrInvalidOperator
+	}
+}
+
+// pow возводит величину в степень; размерность должна остаться целой
+func (q quantity) pow(exponent float64) (quantity, error) {
+	result := quantity{value: math.Pow(q.value, exponent), units: make(map[string]int)}
+	if q.isDimensionless() {
+		return result, nil
+	}
+
+	for i, power := range q.dim {
+		scaled := float64(power) * exponent
+		if scaled != math.Trunc(scaled) {
+			return quantity{}, ErrDimensionMismatch
+		}
+		result.dim[i] = int(scaled)
+	}
+	for name, power := range q.units {
+		scaled := float64(power) * exponent
+		if scaled != math.Trunc(scaled) {
+			// Единицы вывода нельзя возвести в дробную степень - результат будет в СИ
+			result.units = nil
+			break
+		}
+		result.units[name] = int(scaled)
+	}
+	return result.normalize(), nil
+}
+
+// callQuantity вычисляет вызов функции над величинами
+func (c *Calculator) callQuantity(n callNode) (quantity, error) {
+	args := make([]quantity, len(n.args))
+	for i, arg := range n.args {
+		value, err := c.evalQuantity(arg)
+		if err != nil {
+			return quantity{}, err
+		}
+		args[i] = value
+	}
+
+	switch n.name {
+	case "to":
+		// to(величина, единица) - перевод величины в заданные единицы
+		if len(args) != 2 {
+			return quantity{}, ErrArgumentCount
+		}
+		if args[0].dim != args[1].dim {
+			return quantity{}, ErrDimensionMismatch
+		}
+		args[0].units = args[1].units
+		return args[0], nil
+
+	case "sqrt":
+		if len(args) != 1 {
+			return quantity{}, ErrArgumentCount
+		}
+		if args[0].value < 0 {
+			return quantity{}, ErrInvalidArgument
+		}
+		return args[0].pow(0.5)
+	}
+
+	fn, exists := functions[n.name]
+	if !exists {
+		return quantity{}, ErrUnknownFunction
+	}
+	if len(args) < fn.minArgs || (fn.maxArgs >= 0 && len(args) > fn.maxArgs) {
+		return quantity{}, ErrArgumentCount
+	}
+
+	switch n.name {
+	case "abs", "min", "max", "floor", "ceil", "round":
+		// Функции, сохраняющие размерность аргументов
+		for _, arg := range args[1:] {
+			if arg.dim != args[0].dim {
+				return quantity{}, ErrDimensionMismatch
+			}
+		}
+	default:
+		for _, arg := range args {
+			if !arg.isDimensionless() {
+				return quantity{}, ErrDimensionMismatch
+			}
+		}
+	}
+
+	values := make([]float64, len(args))
+	for i, arg := range args {
+		values[i] = arg.value
+	}
+	value, err := fn.call(values)
+	if err != nil {
+		return quantity{}, err
+	}
+	return quantity{value: value, dim: args[0].dim, units: args[0].units}, nil
+}
+
+// isDimensionless проверяет, что величина безразмерная
+func (q quantity) isDimensionless() bool {
+	return q.dim == dimension{}
+}
+
+// normalize убирает единицы вывода с нулевой степенью
+func (q quantity) normalize() quantity {
+	for name, power := range q.units {
+		if power == 0 {
+			delete(q.units, name)
+		}
+	}
+	return q
+}
+
+// export переводит величину в единицы вывода
+func (q quantity) export() Quantity {
+	if q.isDimensionless() {
+		return Quantity{Value: q.value}
+	}
+
+	scale := 1.0
+	var dim dimension
+	for name, power := range q.units {
+		u, _ := lookupUnit(name)
+		scale *= math.Pow(u.scale, float64(power))
+		for i := range dim {
+			dim[i] += u.dim[i] * power
+		}
+	}
+	if dim != q.dim {
+		// Единицы вывода не согласуются с размерностью - выводим в базовых единицах СИ
+		return Quantity{Value: q.value, Unit: formatUnits(baseUnits(q.dim))}
+	}
+
+	return Quantity{Value: q.value / scale, Unit: formatUnits(q.units)}
+}
+
+// baseUnits представляет размерность через базовые единицы СИ
+func baseUnits(dim dimension) map[string]int {
+	result := make(map[string]int)
+	for i, power := range dim {
+		if power != 0 {
+			result[baseUnitNames[i]] = power
+		}
+	}
+	return result
+}
+
+// formatUnits записывает единицы в виде kg*m/s^2 или kg/(m*s^2)
+func formatUnits(powers map[string]int) string {
+	names := make([]string, 0, len(powers))
+	for name := range powers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var numerator, denominator []string
+	for _, name := range names {
+		power := powers[name]
+		term := name
+		if abs := max(power, -power); abs != 1 {
+			term += "^" + strconv.Itoa(abs)
+		}
+		if power > 0 {
+			numerator = append(numerator, term)
+		} else {
+			denominator = append(denominator, term)
+		}
+	}
+
+	result := strings.Join(numerator, "*")
+	if result == "" {
+		result = "1"
+	}
+	switch len(denominator) {
+	case 0:
+	case 1:
+		result += "/" + denominator[0]
+	default:
+		result += "/(" + strings.Join(denominator, "*") + ")"
+	}
+	return result
+}
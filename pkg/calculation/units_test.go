@@ -0,0 +1,51 @@
+package calculation_test
+
+import (
+	"testing"
+
+	"github.com/flexer2006/y.lms_sprint1_Calc/pkg/calculation"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalcQuantity(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected float64
+		unit     string
+		err      error
+	}{
+		{"sum in left unit", "5 km + 300 m", 5.3, "km", nil},
+		{"speed times time", "60 km/h * 2 h", 120, "km", nil},
+		{"conversion", "to(3 ft, m)", 0.9144, "m", nil},
+		{"compound conversion", "to(100 km/h, m/s)", 27.7777778, "m/s", nil},
+		{"prefixed units", "1500 mg + 1 g", 2500, "mg", nil},
+		{"power of unit", "2 m * 3 m", 6, "m^2", nil},
+		{"unit with exponent", "10 m^2 / 2 m", 5, "m", nil},
+		{"square root", "sqrt(16 m^2)", 4, "m", nil},
+		{"dimensionless ratio", "1 km / 250 m", 4, "", nil},
+		{"scalar factor keeps unit", "3 * (2 kg)", 6, "kg", nil},
+		{"plain number", "2 + 2", 4, "", nil},
+		{"derived units", "to(2 kN * 3 m, J)", 6000, "J", nil},
+		{"several units in denominator", "10 kg / (2 m * 5 s^2)", 1, "kg/(m*s^2)", nil},
+		{"mismatched dimensions", "1 m + 1 s", 0, "", calculation.ErrDimensionMismatch},
+		{"mismatched conversion", "to(1 m, s)", 0, "", calculation.ErrDimensionMismatch},
+		{"dimensional function argument", "sin(1 m)", 0, "", calculation.ErrDimensionMismatch},
+		{"unknown unit", "5 parsec", 0, "", calculation.ErrUnknownUnit},
+		{"fractional dimension", "sqrt(2 m)", 0, "", calculation.ErrDimensionMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := calculation.CalcQuantity(tt.input)
+
+			if tt.err != nil {
+				assert.Equal(t, tt.err, err)
+			} else {
+				assert.NoError(t, err)
+				assert.InDelta(t, tt.expected, result.Value, 0.0001)
+				assert.Equal(t, tt.unit, result.Unit)
+			}
+		})
+	}
+}
@@ -0,0 +1,254 @@
+package calculation
+
+// Value значение выражения: Number или *Matrix (список - матрица из одной строки)
+type Value interface {
+	isValue()
+}
+
+// Number числовое значение
+type Number float64
+
+func (Number) isValue() {}
+
+// matrixFunction функция над значениями произвольного типа
+type matrixFunction struct {
+	args int                                 // Количество аргументов
+	call func(args []*Matrix) (Value, error) // Вычисление функции
+}
+
+// matrixFunctions определяет функции линейной алгебры
+var matrixFunctions = map[string]matrixFunction{
+	"transpose": {1, func(args []*Matrix) (Value, error) {
+		return args[0].transpose(), nil
+	}},
+	"det": {1, func(args []*Matrix) (Value, error) {
+		det, err := args[0].determinant()
+		return Number(det), err
+	}},
+	"inv": {1, func(args []*Matrix) (Value, error) {
+		return args[0].inverse()
+	}},
+	"dot": {2, func(args []*Matrix) (Value, error) {
+		product, err := dot(args[0], args[1])
+		return Number(product), err
+	}},
+	"cross": {2, func(args []*Matrix) (Value, error) {
+		return cross(args[0], args[1])
+	}},
+}
+
+// Evaluate вычисляет выражение, значением которого может быть число, матрица,
+// момент времени или длительность: [1, 2; 3, 4] * [5; 6], inv([2, 0; 0, 4]),
+// date("2026-10-17") + 30d
+func Evaluate(expression string) (Value, error) {
+	return NewCalculator().Evaluate(expression)
+}
+
+// Evaluate вычисляет выражение с учетом заданных переменных
+func (c *Calculator) Evaluate(expression string) (Value, error) {
+	root, err := c.parse(expression, parseOptions{durations: true})
+	if err != nil {
+		return nil, err
+	}
+	c.integrationError = 0
+	return c.evalValue(root)
+}
+
+// evalValue вычисляет узел синтаксического дерева с учетом типов значений
+func (c *Calculator) evalValue(n node) (Value, error) {
+	switch n := n.(type) {
+	case numberNode:
+		return Number(n.value), nil
+
+	case variableNode:
+		if value, exists := c.variables[n.name]; exists {
+			return Number(value), nil
+		}
+		if value, exists := constants[n.name]; exists {
+			return Number(value), nil
+		}
+		return nil, ErrUnknownVariable
+
+	case durationNode:
+		return Duration(n.value), nil
+
+	case stringNode:
+		// Строки допустимы только как аргументы функций дат
+		return nil, ErrInvalidArgument
+
+	case matrixNode:
+		return c.evalMatrix(n)
+
+	case unaryNode:
+		operand, err := c.evalValue(n.operand)
+		if err != nil {
+			return nil, err
+		}
+		return applyValueOperation('*', Number(-1), operand)
+
+	case binaryNode:
+		a, err := c.evalValue(n.left)
+		if err != nil {
+			return nil, err
+		}
+		b, err := c.evalValue(n.right)
+		if err != nil {
+			return nil, err
+		}
+		result, err := applyValueOperation(n.op, a, b)
+		if err != nil {
+			return nil, err
+		}
+		return c.checkSpecialValues(result, underflowed(n.op, a, b, result))
+
+	case callNode:
+		result, err := c.callValue(n)
+		if err != nil {
+			return nil, err
+		}
+		return c.checkSpecialValues(result, false)
+
+	default:
+		return nil, ErrInvalidExpression
+	}
+}
+
+// evalMatrix вычисляет элементы матрицы; каждый элемент должен быть числом
+func (c *Calculator) evalMatrix(n matrixNode) (*Matrix, error) {
+	rows := make([][]float64, len(n.rows))
+	for i, row := range n.rows {
+		rows[i] = make([]float64, len(row))
+		for j, element := range row {
+			value, err := c.evalValue(element)
+			if err != nil {
+				return nil, err
+			}
+			number, ok := value.(Number)
+			if !ok {
+				return nil, ErrNotScalar
+			}
+			rows[i][j] = float64(number)
+		}
+	}
+	return NewMatrix(rows)
+}
+
+// applyValueOperation применяет бинарную операцию к числам или матрицам.
+// Умножение матриц - матричное, деление на матрицу - умножение на обратную,
+// степень матрицы - целая; поэлементные операции записываются как .*, ./ и .^
+func applyValueOperation(op rune, a, b Value) (Value, error) {
+	operator, exists := operators[op]
+	if !exists {
+		return nil, ErrInvalidOperator
+	}
+
+	if isTemporal(a) || isTemporal(b) {
+		return applyTemporalOperation(op, a, b)
+	}
+
+	matrixA, aIsMatrix := a.(*Matrix)
+	matrixB, bIsMatrix := b.(*Matrix)
+
+	switch {
+	case op == '*' && aIsMatrix && bIsMatrix:
+		return multiplyMatrices(matrixA, matrixB)
+
+	case op == '/' && bIsMatrix:
+		inverse, err := matrixB.inverse()
+		if err != nil {
+			return nil, err
+		}
+		return applyValueOperation('*', a, inverse)
+
+	case op == '^' && aIsMatrix:
+		exponent, ok := b.(Number)
+		if !ok {
+			return nil, ErrInvalidArgument
+		}
+		return matrixA.power(float64(exponent))
+
+	case op == '^' && bIsMatrix:
+		return nil, ErrInvalidArgument
+
+	default:
+		return elementwise(operator.operation, a, b)
+	}
+}
+
+// callValue вычисляет вызов функции над числами или матрицами
+func (c *Calculator) callValue(n callNode) (Value, error) {
+	if form, exists := specialForms[n.name]; exists && c.isSpecialFormCall(n) {
+		result, err := form(c, n.args)
+		if err != nil {
+			return nil, err
+		}
+		return Number(result), nil
+	}
+
+	if fn, exists := temporalFunctions[n.name]; exists {
+		if len(n.args) < fn.minArgs || len(n.args) > fn.maxArgs {
+			return nil, ErrArgumentCount
+		}
+		return fn.call(c, n.args)
+	}
+
+	args := make([]Value, len(n.args))
+	for i, arg := range n.args {
+		value, err := c.evalValue(arg)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = value
+	}
+
+	if fn, exists := matrixFunctions[n.name]; exists {
+		if len(args) != fn.args {
+			return nil, ErrArgumentCount
+		}
+		matrices := make([]*Matrix, len(args))
+		for i, arg := range args {
+			switch arg := arg.(type) {
+			case *Matrix:
+				matrices[i] = arg
+			case Number:
+				matrices[i] = &Matrix{Rows: 1, Cols: 1, Data: []float64{float64(arg)}}
+			default:
+				return nil, ErrNotScalar
+			}
+		}
+		return fn.call(matrices)
+	}
+
+	if fn, exists := aggregates[n.name]; exists {
+		return fn.apply(args)
+	}
+
+	fn, exists := functions[n.name]
+	if !exists {
+		return nil, ErrUnknownFunction
+	}
+	if len(args) < fn.minArgs || (fn.maxArgs >= 0 && len(args) > fn.maxArgs) {
+		return nil, ErrArgumentCount
+	}
+
+	// Функция одного аргумента применяется к матрице поэлементно
+	if matrix, ok := args[0].(*Matrix); ok && len(args) == 1 {
+		return matrix.mapElements(func(x float64) (float64, error) {
+			return fn.call([]float64{x})
+		})
+	}
+
+	values := make([]float64, len(args))
+	for i, arg := range args {
+		number, ok := arg.(Number)
+		if !ok {
+			return nil, ErrNotScalar
+		}
+		values[i] = float64(number)
+	}
+	result, err := fn.call(values)
+	if err != nil {
+		return nil, err
+	}
+	return Number(result), nil
+}